@@ -0,0 +1,243 @@
+package apigo
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/pkg/errors"
+)
+
+// DefaultAccessLogFormat is a format of the access log used whether none has
+// been provided to the NewAccessLog.
+const DefaultAccessLogFormat = `{
+	"requestId": "$context.requestId",
+	"ip": "$context.identity.sourceIp",
+	"httpMethod": "$context.httpMethod",
+	"resourcePath": "$context.resourcePath",
+	"stage": "$context.stage",
+	"status": "$context.status",
+	"responseLength": "$context.responseLength",
+	"responseLatency": "$context.responseLatency",
+	"isBase64Encoded": "$context.isBase64Encoded"
+}`
+
+// contextVariable matches $context variables in the access log format.
+var contextVariable = regexp.MustCompile(`\$context(\.[A-Za-z0-9_-]+)+`)
+
+// AccessLog is a Gateway middleware which emits one structured log line per
+// invocation. Its format mirrors the access logging of the API Gateway: it is
+// a JSON object which values may contain $context variables (i.e.
+// "$context.requestId"), so log queries can stay the same.
+//
+// Besides variables from the event's Request Context following ones are
+// available: $context.status, $context.responseLength,
+// $context.responseLatency (in milliseconds), $context.isBase64Encoded and
+// $context.error.message.
+type AccessLog struct {
+	Logger *slog.Logger
+	fields []accessLogField
+}
+
+// accessLogField is a single field of the access log.
+type accessLogField struct {
+	key   string
+	value string
+}
+
+// accessLogEntry is an invocation described by the access log.
+type accessLogEntry struct {
+	event    events.APIGatewayProxyRequest
+	response events.APIGatewayProxyResponse
+	err      error
+	latency  time.Duration
+}
+
+// NewAccessLog creates new AccessLog which writes to the logger (or
+// slog.Default() if nil passed) using the format (or DefaultAccessLogFormat
+// if empty string passed).
+func NewAccessLog(logger *slog.Logger, format string) (*AccessLog, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if format == "" {
+		format = DefaultAccessLogFormat
+	}
+
+	fields, err := parseAccessLogFormat(format)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing access log format")
+	}
+
+	return &AccessLog{Logger: logger, fields: fields}, nil
+}
+
+// parseAccessLogFormat returns fields of the JSON object in the order they
+// have been defined.
+func parseAccessLogFormat(format string) ([]accessLogField, error) {
+	dec := json.NewDecoder(strings.NewReader(format))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return nil, errors.New("format must be a JSON object")
+	}
+
+	var fields []accessLogField
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key := t.(string)
+
+		var value string
+		if err := dec.Decode(&value); err != nil {
+			return nil, errors.Wrapf(err, "field %q", key)
+		}
+		fields = append(fields, accessLogField{key, value})
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after JSON object")
+	}
+	return fields, nil
+}
+
+// Middleware logs each invocation handled by the Gateway.
+func (l *AccessLog) Middleware(next ServeFunc) ServeFunc {
+	return func(ctx context.Context, ev events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		start := time.Now()
+		resp, err := next(ctx, ev)
+
+		l.log(ctx, accessLogEntry{
+			event:    ev,
+			response: resp,
+			err:      err,
+			latency:  time.Since(start),
+		})
+
+		return resp, err
+	}
+}
+
+// log emits the access log line of the invocation.
+func (l *AccessLog) log(ctx context.Context, e accessLogEntry) {
+	attrs := make([]slog.Attr, 0, len(l.fields))
+	for _, f := range l.fields {
+		attrs = append(attrs, e.attr(f))
+	}
+	l.Logger.LogAttrs(ctx, slog.LevelInfo, "access", attrs...)
+}
+
+// attr returns a value of the field. Whether the field consists of a single
+// variable then its value is typed, otherwise variables are expanded in
+// a string.
+func (e accessLogEntry) attr(f accessLogField) slog.Attr {
+	if contextVariable.FindString(f.value) == f.value {
+		return slog.Any(f.key, e.variable(f.value))
+	}
+
+	s := contextVariable.ReplaceAllStringFunc(f.value, func(v string) string {
+		return fmt.Sprint(e.variable(v))
+	})
+	return slog.String(f.key, s)
+}
+
+// variable returns a value of the $context variable or "-" whether it is
+// not available (as the API Gateway does).
+func (e accessLogEntry) variable(name string) interface{} {
+	rc := e.event.RequestContext
+	status := e.response.StatusCode
+	if e.err != nil {
+		status = 502
+	}
+
+	switch name {
+	case "$context.requestId":
+		return orDash(rc.RequestID)
+	case "$context.extendedRequestId":
+		return orDash(rc.ExtendedRequestID)
+	case "$context.accountId":
+		return orDash(rc.AccountID)
+	case "$context.apiId":
+		return orDash(rc.APIID)
+	case "$context.domainName":
+		return orDash(rc.DomainName)
+	case "$context.domainPrefix":
+		return orDash(rc.DomainPrefix)
+	case "$context.httpMethod":
+		return orDash(e.event.HTTPMethod)
+	case "$context.path":
+		return orDash(rc.Path)
+	case "$context.protocol":
+		return orDash(rc.Protocol)
+	case "$context.requestTime":
+		return orDash(rc.RequestTime)
+	case "$context.requestTimeEpoch":
+		return rc.RequestTimeEpoch
+	case "$context.resourceId":
+		return orDash(rc.ResourceID)
+	case "$context.resourcePath":
+		return orDash(rc.ResourcePath)
+	case "$context.stage":
+		return orDash(rc.Stage)
+	case "$context.identity.sourceIp":
+		return orDash(rc.Identity.SourceIP)
+	case "$context.identity.userAgent":
+		return orDash(rc.Identity.UserAgent)
+	case "$context.identity.caller":
+		return orDash(rc.Identity.Caller)
+	case "$context.identity.user":
+		return orDash(rc.Identity.User)
+	case "$context.identity.userArn":
+		return orDash(rc.Identity.UserArn)
+	case "$context.identity.apiKeyId":
+		return orDash(rc.Identity.APIKeyID)
+	case "$context.status":
+		// API Gateway logs the status as a string.
+		return strconv.Itoa(status)
+	case "$context.responseLength":
+		return responseLength(e.response)
+	case "$context.responseLatency":
+		return e.latency.Milliseconds()
+	case "$context.isBase64Encoded":
+		return e.response.IsBase64Encoded
+	case "$context.error.message":
+		if e.err != nil {
+			return e.err.Error()
+		}
+		return "-"
+	}
+
+	if key := strings.TrimPrefix(name, "$context.authorizer."); key != name {
+		if v, ok := rc.Authorizer[key]; ok {
+			return v
+		}
+	}
+	return "-"
+}
+
+// responseLength returns a length of the response body in bytes.
+func responseLength(resp events.APIGatewayProxyResponse) int {
+	if !resp.IsBase64Encoded {
+		return len(resp.Body)
+	}
+	return base64.StdEncoding.DecodedLen(len(resp.Body)) - strings.Count(resp.Body, "=")
+}
+
+// orDash returns s or "-" whether it is empty.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
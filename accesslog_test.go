@@ -0,0 +1,80 @@
+package apigo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := NewAccessLog(slog.New(slog.NewJSONHandler(&buf, nil)), "")
+	assert.NoError(t, err)
+
+	g := NewGateway("api.example.com", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("data"))
+	}))
+	g.Middlewares = append(g.Middlewares, l.Middleware)
+
+	_, err = g.Serve(context.TODO(), events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Path:       "/pets",
+		RequestContext: events.APIGatewayProxyRequestContext{
+			RequestID:    "1234",
+			Stage:        "prod",
+			ResourcePath: "/pets",
+			Identity:     events.APIGatewayRequestIdentity{SourceIP: "1.2.3.4"},
+		},
+	})
+	assert.NoError(t, err)
+
+	var line map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "access", line["msg"])
+	assert.Equal(t, "1234", line["requestId"])
+	assert.Equal(t, "1.2.3.4", line["ip"])
+	assert.Equal(t, "POST", line["httpMethod"])
+	assert.Equal(t, "/pets", line["resourcePath"])
+	assert.Equal(t, "prod", line["stage"])
+	assert.Equal(t, "201", line["status"])
+	assert.Equal(t, float64(4), line["responseLength"])
+	assert.Equal(t, true, line["isBase64Encoded"])
+	assert.Contains(t, line, "responseLatency")
+}
+
+func TestAccessLog_format(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := NewAccessLog(
+		slog.New(slog.NewJSONHandler(&buf, nil)),
+		`{"request": "$context.httpMethod $context.path $context.protocol", "user": "$context.authorizer.principalId", "missing": "$context.identity.user"}`,
+	)
+	assert.NoError(t, err)
+
+	l.log(context.TODO(), accessLogEntry{
+		event: events.APIGatewayProxyRequest{
+			HTTPMethod: "GET",
+			RequestContext: events.APIGatewayProxyRequestContext{
+				Path:       "/prod/pets",
+				Protocol:   "HTTP/1.1",
+				Authorizer: map[string]interface{}{"principalId": "johndoe"},
+			},
+		},
+	})
+
+	var line map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "GET /prod/pets HTTP/1.1", line["request"])
+	assert.Equal(t, "johndoe", line["user"])
+	assert.Equal(t, "-", line["missing"])
+
+	_, err = NewAccessLog(nil, `["$context.requestId"]`)
+	assert.Error(t, err)
+}
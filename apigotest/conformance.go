@@ -0,0 +1,207 @@
+package apigotest
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/piotrkubisa/apigo"
+	"github.com/piotrkubisa/apigo/eventconv"
+)
+
+// DefaultConformanceIgnoreHeaders are response headers, which are set by the
+// HTTP server (or the API Gateway) itself, so they are not compared.
+var DefaultConformanceIgnoreHeaders = []string{"Date", "Content-Length", "Transfer-Encoding", "Connection"}
+
+// Conformance checks whether the handler behaves the same behind the
+// httptest.Server and behind the apigo.Gateway. Each request is sent to
+// both of them and their status codes, headers, cookies and bodies are
+// compared. Redirects are not followed, so Location headers are compared
+// too.
+type Conformance struct {
+	Handler http.Handler
+	// Gateway creates the Gateway serving the Handler for the given host.
+	// apigo.NewGateway is used whether it is nil.
+	Gateway func(host string, h http.Handler) *apigo.Gateway
+	// IgnoreHeaders are response headers, which are not compared.
+	// DefaultConformanceIgnoreHeaders are used whether it is nil.
+	IgnoreHeaders []string
+}
+
+// Run sends each request in a subtest (named after its method and target)
+// to the httptest.Server and the Gateway and reports differences in their
+// responses. Requests are expected to be created with httptest.NewRequest.
+func (c *Conformance) Run(t *testing.T, reqs ...*http.Request) {
+	t.Helper()
+
+	srv := httptest.NewServer(c.Handler)
+	defer srv.Close()
+
+	client := &http.Client{
+		Transport: &http.Transport{DisableCompression: true},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	defer client.CloseIdleConnections()
+
+	for _, req := range reqs {
+		req := req
+		t.Run(req.Method+" "+req.URL.RequestURI(), func(t *testing.T) {
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want, err := c.serveHTTP(client, srv.URL, req, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := c.serveGateway(req, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c.compare(t, want, got)
+		})
+	}
+}
+
+// response is a response of either the httptest.Server or the Gateway.
+type response struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// serveHTTP sends the request to the httptest.Server.
+func (c *Conformance) serveHTTP(client *http.Client, base string, req *http.Request, body []byte) (*response, error) {
+	out, err := http.NewRequest(req.Method, base+req.URL.RequestURI(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	out.Host = req.Host
+	out.Header = req.Header.Clone()
+
+	resp, err := client.Do(out)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &response{resp.StatusCode, resp.Header, b}, nil
+}
+
+// serveGateway converts the request to an event and serves it through the
+// Gateway.
+func (c *Conformance) serveGateway(req *http.Request, body []byte) (*response, error) {
+	in, err := http.NewRequest(req.Method, "http://"+req.Host+req.URL.RequestURI(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	in.Header = req.Header.Clone()
+
+	ev, err := eventconv.FromRequest(in)
+	if err != nil {
+		return nil, err
+	}
+
+	newGateway := c.Gateway
+	if newGateway == nil {
+		newGateway = apigo.NewGateway
+	}
+	resp, err := newGateway(req.Host, c.Handler).Serve(context.Background(), ev)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := gatewayBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	return &response{resp.StatusCode, gatewayHeader(resp), b}, nil
+}
+
+// compare reports differences between responses of the httptest.Server and
+// the Gateway.
+func (c *Conformance) compare(t *testing.T, want, got *response) {
+	t.Helper()
+
+	if want.status != got.status {
+		t.Errorf("status: http %d, gateway %d", want.status, got.status)
+	}
+
+	ignore := c.IgnoreHeaders
+	if ignore == nil {
+		ignore = DefaultConformanceIgnoreHeaders
+	}
+	for _, k := range ignore {
+		want.header.Del(k)
+		got.header.Del(k)
+	}
+	for _, k := range headerKeys(want.header, got.header) {
+		w, g := want.header[k], got.header[k]
+		if k == "Set-Cookie" {
+			sort.Strings(w)
+			sort.Strings(g)
+		}
+		if strings.Join(w, "\n") != strings.Join(g, "\n") {
+			t.Errorf("header %s: http %q, gateway %q", k, w, g)
+		}
+	}
+
+	if !bytes.Equal(want.body, got.body) {
+		t.Errorf("body: http %q, gateway %q", want.body, got.body)
+	}
+}
+
+// gatewayHeader returns headers of the Gateway's response.
+func gatewayHeader(resp events.APIGatewayProxyResponse) http.Header {
+	h := make(http.Header)
+	for k, vs := range resp.MultiValueHeaders {
+		for _, v := range vs {
+			h.Add(k, v)
+		}
+	}
+	for k, v := range resp.Headers {
+		if _, ok := h[http.CanonicalHeaderKey(k)]; !ok {
+			h.Set(k, v)
+		}
+	}
+	return h
+}
+
+// gatewayBody returns the decoded body of the Gateway's response.
+func gatewayBody(resp events.APIGatewayProxyResponse) ([]byte, error) {
+	if resp.IsBase64Encoded {
+		return base64.StdEncoding.DecodeString(resp.Body)
+	}
+	return []byte(resp.Body), nil
+}
+
+// headerKeys returns sorted names of headers from both h1 and h2.
+func headerKeys(h1, h2 http.Header) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, h := range []http.Header{h1, h2} {
+		for k := range h {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,117 @@
+package apigotest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/piotrkubisa/apigo"
+)
+
+func probeHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/echo/", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var cookies []string
+		for _, c := range r.Cookies() {
+			cookies = append(cookies, c.Name+"="+c.Value)
+		}
+		query := r.URL.Query()
+		keys := make([]string, 0, len(query))
+		for k := range query {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		fmt.Fprintf(w, "method=%s\nhost=%s\nhost-header=%s\nrequest-uri=%s\npath=%s\nraw-path=%s\nraw-query=%s\n",
+			r.Method, r.Host, r.Header.Get("Host"), r.RequestURI, r.URL.Path, r.URL.RawPath, r.URL.RawQuery)
+		for _, k := range keys {
+			fmt.Fprintf(w, "query %s=%s\n", k, strings.Join(query[k], ","))
+		}
+		fmt.Fprintf(w, "cookies=%s\ncontent-type=%s\ncontent-length=%d\nbody=%s\n",
+			strings.Join(cookies, ";"), r.Header.Get("Content-Type"), r.ContentLength, body)
+	})
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("/empty", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Header().Set("X-After-WriteHeader", "ignored")
+		w.Write([]byte(`{"id":1}`))
+	})
+	mux.HandleFunc("/sniff", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><body>hello</body></html>"))
+	})
+	mux.HandleFunc("/binary", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte{0, 1, 2, 0xff})
+	})
+	mux.HandleFunc("/cookies", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/", HttpOnly: true})
+		http.SetCookie(w, &http.Cookie{Name: "theme", Value: "dark", MaxAge: 3600})
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/echo/target?from=redirect", http.StatusFound)
+	})
+	mux.HandleFunc("/relative/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "next", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/error", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	})
+	return mux
+}
+
+func TestConformance(t *testing.T) {
+	post := httptest.NewRequest("POST", "/echo/form", strings.NewReader("name=Luna&age=3"))
+	post.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	cookies := httptest.NewRequest("GET", "/echo/cookies", nil)
+	cookies.AddCookie(&http.Cookie{Name: "a", Value: "1"})
+	cookies.AddCookie(&http.Cookie{Name: "b", Value: "2"})
+
+	c := &Conformance{Handler: probeHandler(), Gateway: rawQueryGateway}
+	c.Run(t,
+		httptest.NewRequest("GET", "/echo/pets", nil),
+		rawQuery(httptest.NewRequest("GET", "/echo/pets?tag=b&x=%2F&q=a+b&tag=a", nil)),
+		rawQuery(httptest.NewRequest("GET", "/echo/pets?q=a%20b&x=/", nil)),
+		httptest.NewRequest("GET", "/echo/files/a%2Fb", nil),
+		httptest.NewRequest("GET", "/echo/pets/a%20b", nil),
+		httptest.NewRequest("GET", "/echo/pets/%C5%82una", nil),
+		post,
+		cookies,
+		httptest.NewRequest("GET", "/status", nil),
+		httptest.NewRequest("GET", "/empty", nil),
+		httptest.NewRequest("GET", "/json", nil),
+		httptest.NewRequest("GET", "/sniff", nil),
+		httptest.NewRequest("GET", "/binary", nil),
+		httptest.NewRequest("GET", "/cookies", nil),
+		httptest.NewRequest("GET", "/redirect", nil),
+		httptest.NewRequest("GET", "/relative/page", nil),
+		httptest.NewRequest("GET", "/error", nil),
+		httptest.NewRequest("GET", "/missing", nil),
+	)
+}
+
+// rawQueryGateway creates the Gateway, which takes the original query string
+// from the X-Raw-Query header.
+func rawQueryGateway(host string, h http.Handler) *apigo.Gateway {
+	gw := apigo.NewGateway(host, h)
+	gw.Proxy = &apigo.DefaultProxy{Host: host, RawQueryResolver: apigo.HeaderRawQuery("X-Raw-Query")}
+	return gw
+}
+
+// rawQuery sets the X-Raw-Query header of the request, as a proxy in front
+// of the API Gateway would do.
+func rawQuery(req *http.Request) *http.Request {
+	req.Header.Set("X-Raw-Query", req.URL.RawQuery)
+	return req
+}
@@ -0,0 +1,170 @@
+// Package apigotest provides utilities for testing handlers served through
+// the apigo.Gateway.
+package apigotest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/piotrkubisa/apigo"
+)
+
+// UpdateEnv is an environment variable, which enables rewriting of golden
+// files whether it is set to a true value, i.e.
+// APIGOTEST_UPDATE=1 go test ./...
+const UpdateEnv = "APIGOTEST_UPDATE"
+
+// GoldenExt is an extension of golden files, which are stored next to the
+// event files.
+const GoldenExt = ".golden"
+
+// DefaultIgnoreHeaders are response headers, which are not stored in golden
+// files, because they change between invocations.
+var DefaultIgnoreHeaders = []string{"Date", "X-Request-Id", "X-Amzn-Trace-Id"}
+
+// Golden is a normalized response stored in the golden file.
+type Golden struct {
+	StatusCode      int         `json:"statusCode"`
+	Headers         http.Header `json:"headers,omitempty"`
+	IsBase64Encoded bool        `json:"isBase64Encoded,omitempty"`
+	Body            string      `json:"body"`
+	Error           string      `json:"error,omitempty"`
+}
+
+// Snapshot runs events through the Gateway and compares responses against
+// golden files.
+type Snapshot struct {
+	// Serve handles events, i.e. apigo.Gateway.Serve.
+	Serve apigo.ServeFunc
+	// IgnoreHeaders are response headers, which are not compared.
+	// DefaultIgnoreHeaders are used whether it is nil.
+	IgnoreHeaders []string
+	// Update rewrites golden files with the actual responses. Golden files
+	// are also rewritten whether the UpdateEnv environment variable or the
+	// -update flag (registered by the test package) has been set.
+	Update bool
+}
+
+// NewSnapshot creates new Snapshot of responses served by the Gateway.
+func NewSnapshot(g *apigo.Gateway) *Snapshot {
+	return &Snapshot{Serve: g.Serve}
+}
+
+// Run runs each event file (*.json) from the dir in a subtest and compares
+// the response with the golden file of the same name (*.golden). Golden files
+// are written whether the update has been requested.
+func (s *Snapshot) Run(t *testing.T, dir string) {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatalf("no events found in %s", dir)
+	}
+
+	for _, file := range files {
+		file := file
+		name := strings.TrimSuffix(filepath.Base(file), ".json")
+		t.Run(name, func(t *testing.T) {
+			s.compare(t, file, strings.TrimSuffix(file, ".json")+GoldenExt)
+		})
+	}
+}
+
+// compare serves the event from the file and compares the response with
+// the golden file.
+func (s *Snapshot) compare(t *testing.T, file, golden string) {
+	t.Helper()
+
+	payload, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ev events.APIGatewayProxyRequest
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		t.Fatalf("decoding %s: %v", file, err)
+	}
+
+	resp, err := s.Serve(context.Background(), ev)
+	got, err := json.MarshalIndent(s.Normalize(resp, err), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	if s.update() {
+		if err := os.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(golden)
+	if os.IsNotExist(err) {
+		t.Fatalf("golden file %s does not exist, run tests with %s=1", golden, UpdateEnv)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(want, got) {
+		t.Errorf("response does not match %s (run tests with %s=1 to accept it)\nwant:\n%s\ngot:\n%s", golden, UpdateEnv, want, got)
+	}
+}
+
+// update reports whether golden files should be rewritten. The -update flag
+// is looked up lazily, so the package does not register it and does not
+// collide with the flag defined by the test package.
+func (s *Snapshot) update() bool {
+	if s.Update {
+		return true
+	}
+	if ok, _ := strconv.ParseBool(os.Getenv(UpdateEnv)); ok {
+		return true
+	}
+	if f := flag.Lookup("update"); f != nil {
+		ok, _ := strconv.ParseBool(f.Value.String())
+		return ok
+	}
+	return false
+}
+
+// Normalize returns a response (or an error) in a form stored in golden
+// files. Headers and MultiValueHeaders are merged and ignored headers are
+// removed.
+func (s *Snapshot) Normalize(resp events.APIGatewayProxyResponse, err error) Golden {
+	if err != nil {
+		return Golden{Error: err.Error()}
+	}
+
+	h := gatewayHeader(resp)
+	ignore := s.IgnoreHeaders
+	if ignore == nil {
+		ignore = DefaultIgnoreHeaders
+	}
+	for _, k := range ignore {
+		h.Del(k)
+	}
+	if len(h) == 0 {
+		h = nil
+	}
+
+	g := Golden{
+		StatusCode:      resp.StatusCode,
+		Headers:         h,
+		IsBase64Encoded: resp.IsBase64Encoded,
+		Body:            resp.Body,
+	}
+	return g
+}
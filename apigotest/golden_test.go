@@ -0,0 +1,77 @@
+package apigotest
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/piotrkubisa/apigo"
+	"github.com/stretchr/testify/assert"
+)
+
+func testGateway() *apigo.Gateway {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Date", time.Now().Format(http.TimeFormat))
+		w.Header().Set("X-Request-Id", r.Header.Get("X-Request-Id"))
+		fmt.Fprintf(w, `{"hello":%q}`, r.URL.Query().Get("name"))
+	})
+	return apigo.NewGateway("api.example.com", mux)
+}
+
+func TestSnapshot_Run(t *testing.T) {
+	NewSnapshot(testGateway()).Run(t, "testdata")
+}
+
+func TestSnapshot_Run_update(t *testing.T) {
+	dir := t.TempDir()
+
+	event, err := os.ReadFile(filepath.Join("testdata", "hello.json"))
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "hello.json"), event, 0644))
+
+	s := NewSnapshot(testGateway())
+	s.Update = true
+	s.Run(t, dir)
+
+	got, err := os.ReadFile(filepath.Join(dir, "hello.golden"))
+	assert.NoError(t, err)
+	want, err := os.ReadFile(filepath.Join("testdata", "hello.golden"))
+	assert.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+func TestSnapshot_update(t *testing.T) {
+	t.Setenv(UpdateEnv, "")
+	assert.False(t, new(Snapshot).update())
+	assert.True(t, (&Snapshot{Update: true}).update())
+
+	t.Setenv(UpdateEnv, "true")
+	assert.True(t, new(Snapshot).update())
+}
+
+func TestSnapshot_Normalize(t *testing.T) {
+	s := &Snapshot{IgnoreHeaders: []string{"Date"}}
+	g := s.Normalize(events.APIGatewayProxyResponse{
+		StatusCode:        200,
+		Headers:           map[string]string{"date": "now", "content-type": "text/plain", "Set-Cookie": "b=2"},
+		MultiValueHeaders: map[string][]string{"Set-Cookie": {"a=1", "b=2"}},
+		Body:              "ok",
+	}, nil)
+
+	assert.Equal(t, Golden{
+		StatusCode: 200,
+		Headers: http.Header{
+			"Content-Type": {"text/plain"},
+			"Set-Cookie":   {"a=1", "b=2"},
+		},
+		Body: "ok",
+	}, g)
+
+	assert.Equal(t, Golden{Error: "boom"}, s.Normalize(events.APIGatewayProxyResponse{}, fmt.Errorf("boom")))
+}
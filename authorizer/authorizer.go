@@ -0,0 +1,217 @@
+// Package authorizer helps writing AWS API Gateway Lambda authorizers. TOKEN
+// and REQUEST authorizer events of the REST APIs and the HTTP APIs (payload
+// format version 2.0) are decoded to a Request and the Result returned by
+// an AuthorizeFunc is encoded as an IAM policy or a simple response.
+package authorizer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/pkg/errors"
+)
+
+// ErrUnauthorized is returned by the AuthorizeFunc to reply with
+// 401 Unauthorized, i.e. whether the token is missing or malformed.
+var ErrUnauthorized = errors.New("Unauthorized")
+
+// Types of the authorizer events.
+const (
+	TypeToken   = "TOKEN"
+	TypeRequest = "REQUEST"
+)
+
+// Request is an authorization request decoded from the event.
+type Request struct {
+	// Type is either TypeToken or TypeRequest.
+	Type string
+	// Version is a payload format version of the HTTP API events, it is
+	// empty for the REST API events.
+	Version string
+	// Token is an authorization token of the TOKEN events or the first
+	// identity source (or Authorization header) of the REQUEST events.
+	Token string
+	// MethodARN is an ARN of the invoked method (or route).
+	MethodARN      string
+	Method         string
+	Path           string
+	Headers        http.Header
+	Query          url.Values
+	PathParameters map[string]string
+	StageVariables map[string]string
+	SourceIP       string
+	// Payload is the raw event.
+	Payload json.RawMessage
+}
+
+// Result is an outcome of the authorization.
+type Result struct {
+	// Principal identifies the user (principalId).
+	Principal string
+	// Allow grants access to the Resources, which are denied otherwise.
+	Allow bool
+	// Resources are methods the policy applies to, either ARNs or
+	// "METHOD/path" patterns (i.e. "GET/pets/*" or "*/*") relative to the
+	// API and stage of the MethodARN. Only the invoked method is covered
+	// whether it is empty, which is too narrow for the cached authorizers.
+	Resources []string
+	// Context is passed to the integration as the authorizer's context.
+	Context map[string]interface{}
+	// UsageIdentifierKey is an API key of the usage plan.
+	UsageIdentifierKey string
+}
+
+// AuthorizeFunc authorizes the request. Returning ErrUnauthorized (also
+// wrapped) replies with 401 Unauthorized, other errors reply with 500
+// Internal Server Error.
+type AuthorizeFunc func(context.Context, *Request) (Result, error)
+
+// Authorizer handles authorizer events using Authorize.
+type Authorizer struct {
+	Authorize AuthorizeFunc
+	// SimpleResponses replies to the HTTP API (payload format version 2.0)
+	// events with simple responses instead of IAM policies.
+	SimpleResponses bool
+}
+
+// New creates new Authorizer which utilizes f as an Authorizer.Authorize.
+func New(f AuthorizeFunc) *Authorizer {
+	return &Authorizer{Authorize: f}
+}
+
+// ListenAndServe registers a listener of AWS Lambda events.
+func (a *Authorizer) ListenAndServe() {
+	lambda.Start(a)
+}
+
+// Invoke implements the lambda.Handler interface.
+func (a *Authorizer) Invoke(ctx context.Context, payload []byte) ([]byte, error) {
+	r, err := DecodeRequest(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := a.Authorize(ctx, r)
+	if errors.Is(err, ErrUnauthorized) {
+		// API Gateway replies with 401 only whether the error message is
+		// exactly "Unauthorized", so the wrapped error is not returned.
+		return nil, ErrUnauthorized
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Version == "2.0" && a.SimpleResponses {
+		return json.Marshal(events.APIGatewayV2CustomAuthorizerSimpleResponse{
+			IsAuthorized: res.Allow,
+			Context:      res.Context,
+		})
+	}
+
+	resp, err := NewResponse(r.MethodARN, res)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(resp)
+}
+
+// DecodeRequest decodes the TOKEN or REQUEST authorizer event of the REST
+// API or the HTTP API.
+func DecodeRequest(payload []byte) (*Request, error) {
+	var probe struct {
+		Version string `json:"version"`
+		Type    string `json:"type"`
+	}
+	if err := json.Unmarshal(payload, &probe); err != nil {
+		return nil, errors.Wrap(err, "decoding event")
+	}
+
+	r := &Request{Payload: payload, Type: probe.Type}
+	switch {
+	case probe.Version == "2.0":
+		var ev events.APIGatewayV2CustomAuthorizerV2Request
+		if err := json.Unmarshal(payload, &ev); err != nil {
+			return nil, errors.Wrap(err, "decoding event")
+		}
+		r.Version = ev.Version
+		r.MethodARN = ev.RouteArn
+		r.Method = ev.RequestContext.HTTP.Method
+		r.Path = ev.RequestContext.HTTP.Path
+		r.Headers = headers(ev.Headers, nil)
+		r.Query, _ = url.ParseQuery(ev.RawQueryString)
+		r.PathParameters = ev.PathParameters
+		r.StageVariables = ev.StageVariables
+		r.SourceIP = ev.RequestContext.HTTP.SourceIP
+		if len(ev.IdentitySource) > 0 {
+			r.Token = ev.IdentitySource[0]
+		}
+		if len(ev.Cookies) > 0 {
+			r.Headers.Set("Cookie", strings.Join(ev.Cookies, "; "))
+		}
+
+	case probe.Type == TypeToken:
+		var ev events.APIGatewayCustomAuthorizerRequest
+		if err := json.Unmarshal(payload, &ev); err != nil {
+			return nil, errors.Wrap(err, "decoding event")
+		}
+		r.Token = ev.AuthorizationToken
+		r.MethodARN = ev.MethodArn
+		r.Headers = make(http.Header)
+
+	case probe.Type == TypeRequest:
+		var ev events.APIGatewayCustomAuthorizerRequestTypeRequest
+		if err := json.Unmarshal(payload, &ev); err != nil {
+			return nil, errors.Wrap(err, "decoding event")
+		}
+		r.Version = probe.Version
+		r.MethodARN = ev.MethodArn
+		r.Method = ev.HTTPMethod
+		r.Path = ev.Path
+		r.Headers = headers(ev.Headers, ev.MultiValueHeaders)
+		r.Query = query(ev.QueryStringParameters, ev.MultiValueQueryStringParameters)
+		r.PathParameters = ev.PathParameters
+		r.StageVariables = ev.StageVariables
+		r.SourceIP = ev.RequestContext.Identity.SourceIP
+		r.Token = r.Headers.Get("Authorization")
+
+	default:
+		return nil, errors.Errorf("unsupported authorizer type %q", probe.Type)
+	}
+
+	return r, nil
+}
+
+// headers merges single and multi-value headers of the event.
+func headers(single map[string]string, multi map[string][]string) http.Header {
+	h := make(http.Header)
+	for k, vs := range multi {
+		for _, v := range vs {
+			h.Add(k, v)
+		}
+	}
+	for k, v := range single {
+		if _, ok := h[http.CanonicalHeaderKey(k)]; !ok {
+			h.Set(k, v)
+		}
+	}
+	return h
+}
+
+// query merges single and multi-value query string parameters of the event.
+func query(single map[string]string, multi map[string][]string) url.Values {
+	q := make(url.Values)
+	for k, vs := range multi {
+		q[k] = append([]string(nil), vs...)
+	}
+	for k, v := range single {
+		if _, ok := q[k]; !ok {
+			q.Set(k, v)
+		}
+	}
+	return q
+}
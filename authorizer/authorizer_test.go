@@ -0,0 +1,130 @@
+package authorizer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeRequest(t *testing.T) {
+	t.Run("Token", func(t *testing.T) {
+		r, err := DecodeRequest([]byte(`{"type":"TOKEN","authorizationToken":"Bearer abc","methodArn":"` + methodARN + `"}`))
+		assert.NoError(t, err)
+		assert.Equal(t, TypeToken, r.Type)
+		assert.Equal(t, "Bearer abc", r.Token)
+		assert.Equal(t, methodARN, r.MethodARN)
+	})
+
+	t.Run("Request", func(t *testing.T) {
+		r, err := DecodeRequest([]byte(`{
+			"type": "REQUEST",
+			"methodArn": "` + methodARN + `",
+			"httpMethod": "GET",
+			"path": "/pets/1",
+			"headers": {"authorization": "Bearer abc"},
+			"multiValueHeaders": {"Accept": ["text/html", "application/json"]},
+			"queryStringParameters": {"q": "b"},
+			"multiValueQueryStringParameters": {"q": ["a", "b"]},
+			"pathParameters": {"id": "1"},
+			"requestContext": {"identity": {"sourceIp": "1.2.3.4"}}
+		}`))
+		assert.NoError(t, err)
+		assert.Equal(t, TypeRequest, r.Type)
+		assert.Equal(t, "Bearer abc", r.Token)
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "/pets/1", r.Path)
+		assert.Equal(t, []string{"text/html", "application/json"}, r.Headers.Values("Accept"))
+		assert.Equal(t, []string{"a", "b"}, r.Query["q"])
+		assert.Equal(t, "1", r.PathParameters["id"])
+		assert.Equal(t, "1.2.3.4", r.SourceIP)
+	})
+
+	t.Run("V2", func(t *testing.T) {
+		r, err := DecodeRequest([]byte(`{
+			"version": "2.0",
+			"type": "REQUEST",
+			"routeArn": "arn:aws:execute-api:us-east-1:123456789012:abcdef1234/$default/GET/pets",
+			"identitySource": ["Bearer abc"],
+			"rawQueryString": "q=a&q=b",
+			"cookies": ["a=1", "b=2"],
+			"headers": {"authorization": "Bearer abc"},
+			"requestContext": {"http": {"method": "GET", "path": "/pets", "sourceIp": "1.2.3.4"}}
+		}`))
+		assert.NoError(t, err)
+		assert.Equal(t, "2.0", r.Version)
+		assert.Equal(t, "Bearer abc", r.Token)
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "/pets", r.Path)
+		assert.Equal(t, []string{"a", "b"}, r.Query["q"])
+		assert.Equal(t, "a=1; b=2", r.Headers.Get("Cookie"))
+		assert.Equal(t, "1.2.3.4", r.SourceIP)
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		_, err := DecodeRequest([]byte(`{"type":"OTHER"}`))
+		assert.Error(t, err)
+		_, err = DecodeRequest([]byte(`{`))
+		assert.Error(t, err)
+	})
+}
+
+func TestAuthorizer_Invoke(t *testing.T) {
+	a := New(func(ctx context.Context, r *Request) (Result, error) {
+		switch r.Token {
+		case "":
+			return Result{}, ErrUnauthorized
+		case "Bearer expired":
+			return Result{}, errors.Wrap(ErrUnauthorized, "token expired")
+		case "Bearer revoked":
+			return Result{}, fmt.Errorf("token revoked: %w", ErrUnauthorized)
+		case "Bearer admin":
+			return Result{Principal: "admin", Allow: true, Resources: []string{"*"}, Context: map[string]interface{}{"role": "admin"}}, nil
+		}
+		return Result{Principal: "guest"}, nil
+	})
+
+	t.Run("Allow", func(t *testing.T) {
+		out, err := a.Invoke(context.TODO(), []byte(`{"type":"TOKEN","authorizationToken":"Bearer admin","methodArn":"`+methodARN+`"}`))
+		assert.NoError(t, err)
+
+		var resp events.APIGatewayCustomAuthorizerResponse
+		assert.NoError(t, json.Unmarshal(out, &resp))
+		assert.Equal(t, "admin", resp.PrincipalID)
+		assert.Equal(t, "Allow", resp.PolicyDocument.Statement[0].Effect)
+		assert.Equal(t, []string{"arn:aws:execute-api:us-east-1:123456789012:abcdef1234/prod/*/*"}, resp.PolicyDocument.Statement[0].Resource)
+		assert.Equal(t, "admin", resp.Context["role"])
+	})
+
+	t.Run("Deny", func(t *testing.T) {
+		out, err := a.Invoke(context.TODO(), []byte(`{"type":"TOKEN","authorizationToken":"Bearer guest","methodArn":"`+methodARN+`"}`))
+		assert.NoError(t, err)
+		assert.Contains(t, string(out), `"Effect":"Deny"`)
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		_, err := a.Invoke(context.TODO(), []byte(`{"type":"TOKEN","methodArn":"`+methodARN+`"}`))
+		assert.Equal(t, ErrUnauthorized, err)
+		assert.EqualError(t, err, "Unauthorized")
+	})
+
+	t.Run("UnauthorizedWrapped", func(t *testing.T) {
+		for _, token := range []string{"Bearer expired", "Bearer revoked"} {
+			_, err := a.Invoke(context.TODO(), []byte(`{"type":"TOKEN","authorizationToken":"`+token+`","methodArn":"`+methodARN+`"}`))
+			assert.Equal(t, ErrUnauthorized, err)
+			assert.EqualError(t, err, "Unauthorized")
+		}
+	})
+
+	t.Run("SimpleResponse", func(t *testing.T) {
+		a := *a
+		a.SimpleResponses = true
+		out, err := a.Invoke(context.TODO(), []byte(`{"version":"2.0","type":"REQUEST","identitySource":["Bearer admin"],"routeArn":"`+methodARN+`"}`))
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"isAuthorized":true,"context":{"role":"admin"}}`, string(out))
+	})
+}
@@ -0,0 +1,151 @@
+package authorizer
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/pkg/errors"
+)
+
+// PolicyVersion is a version of the IAM policy language.
+const PolicyVersion = "2012-10-17"
+
+// MethodARN is an ARN of the API Gateway method, i.e.
+// "arn:aws:execute-api:us-east-1:123456789012:abcdef1234/prod/GET/pets/1".
+type MethodARN struct {
+	Partition string
+	Region    string
+	AccountID string
+	APIID     string
+	Stage     string
+	Method    string
+	// Resource is a path of the method without leading slash.
+	Resource string
+}
+
+// ParseMethodARN parses the method (or route) ARN from the authorizer event.
+func ParseMethodARN(arn string) (MethodARN, error) {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != "execute-api" {
+		return MethodARN{}, errors.Errorf("invalid method ARN %q", arn)
+	}
+
+	path := strings.SplitN(parts[5], "/", 4)
+	if len(path) < 3 {
+		return MethodARN{}, errors.Errorf("invalid method ARN %q", arn)
+	}
+
+	m := MethodARN{
+		Partition: parts[1],
+		Region:    parts[3],
+		AccountID: parts[4],
+		APIID:     path[0],
+		Stage:     path[1],
+		Method:    path[2],
+	}
+	if len(path) == 4 {
+		m.Resource = path[3]
+	}
+	return m, nil
+}
+
+// String returns the ARN.
+func (m MethodARN) String() string {
+	return "arn:" + m.Partition + ":execute-api:" + m.Region + ":" + m.AccountID + ":" +
+		m.APIID + "/" + m.Stage + "/" + m.Method + "/" + m.Resource
+}
+
+// Route returns an ARN of the method and the path of the same API and stage.
+// Both of them may contain "*" wildcards (i.e. Route("*", "/pets/*")).
+func (m MethodARN) Route(method, path string) string {
+	m.Method = method
+	m.Resource = strings.TrimPrefix(path, "/")
+	return m.String()
+}
+
+// Resolve resolves the resource of the policy. ARNs are used verbatim,
+// "METHOD/path" patterns are resolved relative to the API and stage of the
+// method ARN and a single "*" covers all methods of the stage.
+func (m MethodARN) Resolve(resource string) string {
+	switch {
+	case strings.HasPrefix(resource, "arn:"):
+		return resource
+	case resource == "*":
+		return m.Route("*", "*")
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(resource, "/"), "/", 2)
+	if len(parts) == 1 {
+		return m.Route(parts[0], "")
+	}
+	return m.Route(parts[0], parts[1])
+}
+
+// Policy returns an IAM policy document, which allows or denies invoking
+// the resources.
+func Policy(allow bool, resources ...string) events.APIGatewayCustomAuthorizerPolicy {
+	effect := "Deny"
+	if allow {
+		effect = "Allow"
+	}
+	return events.APIGatewayCustomAuthorizerPolicy{
+		Version: PolicyVersion,
+		Statement: []events.IAMPolicyStatement{{
+			Action:   []string{"execute-api:Invoke"},
+			Effect:   effect,
+			Resource: resources,
+		}},
+	}
+}
+
+// NewResponse creates an authorizer response with the IAM policy for the
+// result of the authorization of the method.
+func NewResponse(methodARN string, res Result) (events.APIGatewayCustomAuthorizerResponse, error) {
+	resources := []string{methodARN}
+	if len(res.Resources) > 0 {
+		m, err := ParseMethodARN(methodARN)
+		if err != nil {
+			return events.APIGatewayCustomAuthorizerResponse{}, err
+		}
+		resources = make([]string, len(res.Resources))
+		for i, r := range res.Resources {
+			resources[i] = m.Resolve(r)
+		}
+	}
+
+	ctx, err := flattenContext(res.Context)
+	if err != nil {
+		return events.APIGatewayCustomAuthorizerResponse{}, err
+	}
+
+	return events.APIGatewayCustomAuthorizerResponse{
+		PrincipalID:        res.Principal,
+		PolicyDocument:     Policy(res.Allow, resources...),
+		Context:            ctx,
+		UsageIdentifierKey: res.UsageIdentifierKey,
+	}, nil
+}
+
+// flattenContext encodes values of the context other than strings, numbers
+// and booleans as JSON strings, because the API Gateway rejects them.
+func flattenContext(ctx map[string]interface{}) (map[string]interface{}, error) {
+	if ctx == nil {
+		return nil, nil
+	}
+
+	out := make(map[string]interface{}, len(ctx))
+	for k, v := range ctx {
+		switch v.(type) {
+		case string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, json.Number:
+			out[k] = v
+		default:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, errors.Wrapf(err, "encoding context %q", k)
+			}
+			out[k] = string(b)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,81 @@
+package authorizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const methodARN = "arn:aws:execute-api:us-east-1:123456789012:abcdef1234/prod/GET/pets/1"
+
+func TestParseMethodARN(t *testing.T) {
+	m, err := ParseMethodARN(methodARN)
+	assert.NoError(t, err)
+	assert.Equal(t, MethodARN{
+		Partition: "aws",
+		Region:    "us-east-1",
+		AccountID: "123456789012",
+		APIID:     "abcdef1234",
+		Stage:     "prod",
+		Method:    "GET",
+		Resource:  "pets/1",
+	}, m)
+	assert.Equal(t, methodARN, m.String())
+
+	root, err := ParseMethodARN("arn:aws:execute-api:us-east-1:123456789012:abcdef1234/$default/GET/")
+	assert.NoError(t, err)
+	assert.Equal(t, "$default", root.Stage)
+	assert.Equal(t, "", root.Resource)
+
+	for _, arn := range []string{"", "arn:aws:s3:::bucket", "arn:aws:execute-api:us-east-1:123456789012:abcdef1234/prod"} {
+		_, err := ParseMethodARN(arn)
+		assert.Error(t, err, arn)
+	}
+}
+
+func TestMethodARN_Resolve(t *testing.T) {
+	m, err := ParseMethodARN(methodARN)
+	assert.NoError(t, err)
+
+	base := "arn:aws:execute-api:us-east-1:123456789012:abcdef1234/prod/"
+	assert.Equal(t, base+"*/*", m.Resolve("*"))
+	assert.Equal(t, base+"*/*", m.Resolve("*/*"))
+	assert.Equal(t, base+"GET/pets/*", m.Resolve("GET/pets/*"))
+	assert.Equal(t, base+"POST/", m.Resolve("POST/"))
+	assert.Equal(t, base+"DELETE/", m.Resolve("DELETE"))
+	assert.Equal(t, "arn:aws:execute-api:eu-west-1:1:x/y/*/*", m.Resolve("arn:aws:execute-api:eu-west-1:1:x/y/*/*"))
+}
+
+func TestNewResponse(t *testing.T) {
+	resp, err := NewResponse(methodARN, Result{
+		Principal: "user-1",
+		Allow:     true,
+		Resources: []string{"GET/pets/*", "*/users/user-1"},
+		Context: map[string]interface{}{
+			"name":   "Luna",
+			"admin":  false,
+			"age":    3,
+			"groups": []string{"a", "b"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", resp.PrincipalID)
+	assert.Equal(t, Policy(true,
+		"arn:aws:execute-api:us-east-1:123456789012:abcdef1234/prod/GET/pets/*",
+		"arn:aws:execute-api:us-east-1:123456789012:abcdef1234/prod/*/users/user-1",
+	), resp.PolicyDocument)
+	assert.Equal(t, map[string]interface{}{
+		"name":   "Luna",
+		"admin":  false,
+		"age":    3,
+		"groups": `["a","b"]`,
+	}, resp.Context)
+
+	deny, err := NewResponse(methodARN, Result{Principal: "user-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, Policy(false, methodARN), deny.PolicyDocument)
+	assert.Nil(t, deny.Context)
+
+	_, err = NewResponse("invalid", Result{Allow: true, Resources: []string{"*"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,176 @@
+package apigo
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/pkg/errors"
+)
+
+// ClientIPResolver determines an address of the client which has sent
+// the request to the API Gateway. Returned address may contain a port
+// (i.e. "1.2.3.4:5678").
+type ClientIPResolver interface {
+	ResolveClientIP(events.APIGatewayProxyRequest) string
+}
+
+// ClientIPResolverFunc implements the ClientIPResolver interface to allow use
+// of ordinary function as a resolver.
+type ClientIPResolverFunc func(events.APIGatewayProxyRequest) string
+
+// ResolveClientIP calls f(ev).
+func (f ClientIPResolverFunc) ResolveClientIP(ev events.APIGatewayProxyRequest) string {
+	return f(ev)
+}
+
+// SourceIP resolves a client IP from the Identity of the event's
+// Request Context.
+var SourceIP ClientIPResolver = ClientIPResolverFunc(func(ev events.APIGatewayProxyRequest) string {
+	return ev.RequestContext.Identity.SourceIP
+})
+
+// TrustedProxies resolves a client IP when requests are passed through
+// proxies (i.e. CloudFront distribution) in front of the API Gateway.
+//
+// Whether the source IP of the event belongs to the trusted networks, then
+// the CloudFront-Viewer-Address header is used or the X-Forwarded-For chain
+// is walked from right to left and the first untrusted address is returned.
+// The source IP is returned whether the chain contains an invalid address
+// before the untrusted one or all addresses are trusted, because the rest of
+// the chain could have been set by the client.
+type TrustedProxies struct {
+	Networks []*net.IPNet
+}
+
+// NewTrustedProxies creates new TrustedProxies from the list of networks in
+// CIDR notation (i.e. "10.0.0.0/8"). Single IP addresses are accepted too.
+func NewTrustedProxies(cidrs ...string) (*TrustedProxies, error) {
+	tp := &TrustedProxies{}
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing trusted network %q", cidr)
+		}
+		tp.Networks = append(tp.Networks, n)
+	}
+	return tp, nil
+}
+
+// ResolveClientIP returns an address of the client, which has been
+// determined from the source IP and the forwarding headers.
+func (tp *TrustedProxies) ResolveClientIP(ev events.APIGatewayProxyRequest) string {
+	ip := ev.RequestContext.Identity.SourceIP
+	if !tp.trusted(ip) {
+		return ip
+	}
+
+	h := EventHeaders(ev)
+	if addr := viewerAddress(h.Get("CloudFront-Viewer-Address")); validIP(addr) {
+		return addr
+	}
+
+	hops := strings.Split(strings.Join(h.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		if !validIP(hop) {
+			break
+		}
+		if !tp.trusted(hop) {
+			return hop
+		}
+	}
+
+	return ip
+}
+
+// validIP reports whether the address (which may contain a port) is a valid
+// IP address.
+func validIP(addr string) bool {
+	return net.ParseIP(hostIP(addr)) != nil
+}
+
+// trusted reports whether ip (which may contain a port) belongs to any of
+// the trusted networks.
+func (tp *TrustedProxies) trusted(ip string) bool {
+	parsed := net.ParseIP(hostIP(ip))
+	if parsed == nil {
+		return false
+	}
+	for _, n := range tp.Networks {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// viewerAddress returns the CloudFront-Viewer-Address in host:port form.
+// CloudFront always appends a port, also to IPv6 addresses without brackets
+// (i.e. "2001:db8::1:46532"), so the port is split off at the last colon.
+func viewerAddress(addr string) string {
+	i := strings.LastIndexByte(addr, ':')
+	if i < 0 || strings.HasPrefix(addr, "[") {
+		return addr
+	}
+	if ip := net.ParseIP(addr[:i]); ip != nil && isPort(addr[i+1:]) {
+		return net.JoinHostPort(addr[:i], addr[i+1:])
+	}
+	return addr
+}
+
+// remoteAddr returns the address in host:port form, as it is expected in
+// http.Request.RemoteAddr. The port defaults to "0" whether it is unknown.
+func remoteAddr(addr string) string {
+	if addr == "" {
+		return ""
+	}
+	host, port := splitAddr(addr)
+	if port == "" {
+		port = "0"
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// hostIP returns the IP address without a port.
+func hostIP(addr string) string {
+	host, _ := splitAddr(addr)
+	return host
+}
+
+// splitAddr splits the address into a host and a port (empty whether it is
+// unknown). Besides host:port forms, unbracketed IPv6 addresses followed by
+// a port (i.e. "2001:db8::1:46532") are split, whether they are not valid
+// IP addresses as a whole.
+func splitAddr(addr string) (string, string) {
+	if host, port, err := net.SplitHostPort(addr); err == nil {
+		return host, port
+	}
+
+	addr = strings.Trim(addr, "[]")
+	if net.ParseIP(addr) != nil {
+		return addr, ""
+	}
+	if i := strings.LastIndexByte(addr, ':'); i > 0 && net.ParseIP(addr[:i]) != nil && isPort(addr[i+1:]) {
+		return addr[:i], addr[i+1:]
+	}
+	return addr, ""
+}
+
+// isPort reports whether s is a decimal port number.
+func isPort(s string) bool {
+	n, err := strconv.Atoi(s)
+	return err == nil && n >= 0 && n <= 65535 && s[0] != '+'
+}
@@ -0,0 +1,187 @@
+package apigo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTrustedProxies(t *testing.T) {
+	tp, err := NewTrustedProxies("10.0.0.0/8", "192.168.1.1", "2001:db8::1")
+	assert.NoError(t, err)
+	assert.Len(t, tp.Networks, 3)
+	assert.True(t, tp.trusted("10.1.2.3"))
+	assert.True(t, tp.trusted("192.168.1.1"))
+	assert.False(t, tp.trusted("192.168.1.2"))
+	assert.True(t, tp.trusted("2001:db8::1"))
+
+	_, err = NewTrustedProxies("10.0.0.0/33")
+	assert.Error(t, err)
+}
+
+func TestTrustedProxies_ResolveClientIP(t *testing.T) {
+	tp, err := NewTrustedProxies("10.0.0.0/8", "130.176.0.0/16", "2001:db8:ffff::/48")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		sourceIP string
+		headers  map[string]string
+		want     string
+	}{
+		{
+			name:     "Untrusted",
+			sourceIP: "1.2.3.4",
+			headers:  map[string]string{"X-Forwarded-For": "5.6.7.8"},
+			want:     "1.2.3.4",
+		},
+		{
+			name:     "ViewerAddress",
+			sourceIP: "130.176.1.1",
+			headers: map[string]string{
+				"CloudFront-Viewer-Address": "5.6.7.8:46532",
+				"X-Forwarded-For":           "9.9.9.9, 5.6.7.8",
+			},
+			want: "5.6.7.8:46532",
+		},
+		{
+			name:     "ForwardedFor",
+			sourceIP: "130.176.1.1",
+			headers:  map[string]string{"X-Forwarded-For": "9.9.9.9, 5.6.7.8, 10.0.0.1"},
+			want:     "5.6.7.8",
+		},
+		{
+			name:     "ViewerAddressIPv6",
+			sourceIP: "130.176.1.1",
+			headers:  map[string]string{"CloudFront-Viewer-Address": "2001:db8::1:46532"},
+			want:     "[2001:db8::1]:46532",
+		},
+		{
+			name:     "ViewerAddressIPv6HexPort",
+			sourceIP: "130.176.1.1",
+			headers:  map[string]string{"CloudFront-Viewer-Address": "2001:db8::1:443"},
+			want:     "[2001:db8::1]:443",
+		},
+		{
+			name:     "ForwardedForIPv6",
+			sourceIP: "130.176.1.1",
+			headers:  map[string]string{"X-Forwarded-For": "2001:db8::9, 2001:db8::1:2, 10.0.0.1"},
+			want:     "2001:db8::1:2",
+		},
+		{
+			name:     "ForwardedForIPv6Trusted",
+			sourceIP: "2001:db8:ffff::1",
+			headers:  map[string]string{"X-Forwarded-For": "2001:db8::5, [2001:db8:ffff::2]:8080"},
+			want:     "2001:db8::5",
+		},
+		{
+			name:     "AllTrusted",
+			sourceIP: "10.0.0.2",
+			headers:  map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.1"},
+			want:     "10.0.0.2",
+		},
+		{
+			name:     "ForwardedForInvalid",
+			sourceIP: "130.176.1.1",
+			headers:  map[string]string{"X-Forwarded-For": "5.6.7.8, garbage, 10.0.0.1"},
+			want:     "130.176.1.1",
+		},
+		{
+			name:     "ForwardedForInvalidBeyondClient",
+			sourceIP: "130.176.1.1",
+			headers:  map[string]string{"X-Forwarded-For": "garbage, 5.6.7.8, 10.0.0.1"},
+			want:     "5.6.7.8",
+		},
+		{
+			name:     "ViewerAddressInvalid",
+			sourceIP: "130.176.1.1",
+			headers: map[string]string{
+				"CloudFront-Viewer-Address": "garbage:1234",
+				"X-Forwarded-For":           "5.6.7.8",
+			},
+			want: "5.6.7.8",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := events.APIGatewayProxyRequest{
+				Headers: tt.headers,
+				RequestContext: events.APIGatewayProxyRequestContext{
+					Identity: events.APIGatewayRequestIdentity{SourceIP: tt.sourceIP},
+				},
+			}
+			assert.Equal(t, tt.want, tp.ResolveClientIP(e))
+		})
+	}
+}
+
+func TestDefaultProxy_clientIPResolver(t *testing.T) {
+	tp, err := NewTrustedProxies("130.176.0.0/16")
+	assert.NoError(t, err)
+
+	e := events.APIGatewayProxyRequest{
+		Path: "/pets",
+		Headers: map[string]string{
+			"CloudFront-Viewer-Address": "[2001:db8::2]:46532",
+		},
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Identity: events.APIGatewayRequestIdentity{SourceIP: "130.176.1.1"},
+		},
+	}
+
+	p := &DefaultProxy{ClientIPResolver: tp}
+	r, err := p.Transform(context.TODO(), e)
+	assert.NoError(t, err)
+	assert.Equal(t, "[2001:db8::2]:46532", r.RemoteAddr)
+
+	ip, ok := ClientIP(r.Context())
+	assert.True(t, ok)
+	assert.Equal(t, "2001:db8::2", ip)
+}
+
+func TestRemoteAddr(t *testing.T) {
+	tests := []struct {
+		addr   string
+		remote string
+		ip     string
+	}{
+		{"1.2.3.4", "1.2.3.4:0", "1.2.3.4"},
+		{"1.2.3.4:5678", "1.2.3.4:5678", "1.2.3.4"},
+		{"2001:db8::1", "[2001:db8::1]:0", "2001:db8::1"},
+		{"[2001:db8::1]", "[2001:db8::1]:0", "2001:db8::1"},
+		{"[2001:db8::1]:46532", "[2001:db8::1]:46532", "2001:db8::1"},
+		{"2001:db8::1:46532", "[2001:db8::1]:46532", "2001:db8::1"},
+		{"2001:db8::1:2", "[2001:db8::1:2]:0", "2001:db8::1:2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			assert.Equal(t, tt.remote, remoteAddr(tt.addr))
+			assert.Equal(t, tt.ip, hostIP(tt.addr))
+		})
+	}
+}
+
+func TestDefaultProxy_clientIPResolver_viewerAddressIPv6(t *testing.T) {
+	tp, err := NewTrustedProxies("130.176.0.0/16")
+	assert.NoError(t, err)
+
+	e := events.APIGatewayProxyRequest{
+		Path:    "/pets",
+		Headers: map[string]string{"CloudFront-Viewer-Address": "2001:db8::1:46532"},
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Identity: events.APIGatewayRequestIdentity{SourceIP: "130.176.1.1"},
+		},
+	}
+
+	r, err := (&DefaultProxy{ClientIPResolver: tp}).Transform(context.TODO(), e)
+	assert.NoError(t, err)
+	assert.Equal(t, "[2001:db8::1]:46532", r.RemoteAddr)
+
+	ip, ok := ClientIP(r.Context())
+	assert.True(t, ok)
+	assert.Equal(t, "2001:db8::1", ip)
+}
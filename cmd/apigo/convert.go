@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/piotrkubisa/apigo/eventconv"
+	"github.com/pkg/errors"
+)
+
+const convertUsage = `Usage:
+
+	apigo convert [flags] <file|->
+
+Convert reads a HAR archive or a curl command line from the file (or
+standard input when "-" is given) and writes API Gateway events as JSON.
+Single event is written as an object, otherwise as an array.
+
+Flags:
+`
+
+func runConvert(args []string) error {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	v2 := fs.Bool("v2", false, "write HTTP API (payload format version 2.0) events")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), convertUsage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	var in []byte
+	var err error
+	if fs.Arg(0) == "-" {
+		in, err = io.ReadAll(os.Stdin)
+	} else {
+		in, err = os.ReadFile(fs.Arg(0))
+	}
+	if err != nil {
+		return err
+	}
+
+	return convert(os.Stdout, in, *v2)
+}
+
+// convert writes events converted from the HAR archive or curl command line.
+func convert(w io.Writer, in []byte, v2 bool) error {
+	var reqs []*http.Request
+	if trimmed := bytes.TrimSpace(in); len(trimmed) > 0 && trimmed[0] == '{' {
+		rs, err := eventconv.ParseHAR(bytes.NewReader(trimmed))
+		if err != nil {
+			return err
+		}
+		reqs = rs
+	} else {
+		r, err := eventconv.ParseCurl(string(in))
+		if err != nil {
+			return err
+		}
+		reqs = append(reqs, r)
+	}
+
+	evs := make([]interface{}, 0, len(reqs))
+	for i, r := range reqs {
+		var ev interface{}
+		var err error
+		if v2 {
+			ev, err = eventconv.FromRequestV2(r)
+		} else {
+			ev, err = eventconv.FromRequest(r)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "converting request %d", i)
+		}
+		evs = append(evs, ev)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if len(evs) == 1 {
+		return enc.Encode(evs[0])
+	}
+	return enc.Encode(evs)
+}
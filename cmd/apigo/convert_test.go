@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvert_curl(t *testing.T) {
+	var buf bytes.Buffer
+	err := convert(&buf, []byte(`curl -X DELETE 'https://api.example.com/pets/luna'`), false)
+	assert.NoError(t, err)
+
+	var ev events.APIGatewayProxyRequest
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &ev))
+	assert.Equal(t, "DELETE", ev.HTTPMethod)
+	assert.Equal(t, "/pets/luna", ev.Path)
+}
+
+func TestConvert_har(t *testing.T) {
+	har := `{"log": {"entries": [
+		{"request": {"method": "GET", "url": "https://api.example.com/pets", "headers": []}},
+		{"request": {"method": "GET", "url": "https://api.example.com/toys", "headers": []}}
+	]}}`
+
+	var buf bytes.Buffer
+	assert.NoError(t, convert(&buf, []byte(har), true))
+
+	var evs []events.APIGatewayV2HTTPRequest
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &evs))
+	if assert.Len(t, evs, 2) {
+		assert.Equal(t, "/pets", evs[0].RawPath)
+		assert.Equal(t, "/toys", evs[1].RawPath)
+	}
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/piotrkubisa/apigo/runtimeapi"
+	"github.com/pkg/errors"
+)
+
+const invokeUsage = `Usage:
+
+	apigo invoke [flags] <package|binary> [event.json]
+
+Invoke builds the package (or runs the binary), which calls
+apigo.ListenAndServe, against a local Lambda Runtime API emulator and
+pushes the API Gateway event to it. Event is read from the file (or
+standard input when "-" is given) and it may be modified with flags.
+
+Flags:
+`
+
+// headerFlags collects repeated -H flags.
+type headerFlags []string
+
+func (h *headerFlags) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *headerFlags) Set(v string) error {
+	if !strings.Contains(v, ":") {
+		return errors.Errorf("header %q must be in \"Name: value\" form", v)
+	}
+	*h = append(*h, v)
+	return nil
+}
+
+// overrides modify the event loaded from the file.
+type overrides struct {
+	method  string
+	path    string
+	headers headerFlags
+	body    string
+}
+
+func runInvoke(args []string) error {
+	var o overrides
+	fs := flag.NewFlagSet("invoke", flag.ExitOnError)
+	fs.StringVar(&o.method, "X", "", "override HTTP method")
+	fs.StringVar(&o.path, "path", "", "override path (with an optional query string)")
+	fs.Var(&o.headers, "H", "add header in \"Name: value\" form (repeatable)")
+	fs.StringVar(&o.body, "d", "", "override body, \"@file\" reads it from the file")
+	timeout := fs.Duration("timeout", 30*time.Second, "maximum time to wait for the response (excluding the build)")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), invokeUsage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ev, err := loadEvent(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	if err := o.apply(&ev); err != nil {
+		return err
+	}
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	bin, cleanup, err := build(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	// Timeout starts after the build, so a slow (i.e. cold cache) build
+	// does not count towards the time of the invocation.
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	res, err := invoke(ctx, bin, payload)
+	if err != nil {
+		return err
+	}
+	if res.Error != nil {
+		return errors.Wrap(res.Error, "function error")
+	}
+
+	var resp events.APIGatewayProxyResponse
+	if err := json.Unmarshal(res.Payload, &resp); err != nil {
+		return errors.Wrap(err, "decoding response")
+	}
+	return printResponse(os.Stdout, resp)
+}
+
+// loadEvent reads the event from the file, standard input ("-") or returns
+// a GET / event whether path is empty.
+func loadEvent(path string) (events.APIGatewayProxyRequest, error) {
+	ev := events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet, Path: "/"}
+	if path == "" {
+		return ev, nil
+	}
+
+	var b []byte
+	var err error
+	if path == "-" {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return ev, errors.Wrap(err, "reading event")
+	}
+
+	ev = events.APIGatewayProxyRequest{}
+	if err := json.Unmarshal(b, &ev); err != nil {
+		return ev, errors.Wrap(err, "decoding event")
+	}
+	return ev, nil
+}
+
+// apply modifies the event with the overrides.
+func (o overrides) apply(ev *events.APIGatewayProxyRequest) error {
+	if o.method != "" {
+		ev.HTTPMethod = strings.ToUpper(o.method)
+		ev.RequestContext.HTTPMethod = ev.HTTPMethod
+	}
+
+	if o.path != "" {
+		u, err := url.Parse(o.path)
+		if err != nil {
+			return errors.Wrap(err, "parsing path")
+		}
+		ev.Path = u.Path
+		ev.RequestContext.Path = ""
+		// Resource and path parameters of the event file describe the
+		// previous path, so they are not valid anymore.
+		ev.Resource = ""
+		ev.RequestContext.ResourcePath = ""
+		ev.PathParameters = nil
+		ev.QueryStringParameters = nil
+		ev.MultiValueQueryStringParameters = nil
+		if u.RawQuery != "" {
+			q := u.Query()
+			ev.MultiValueQueryStringParameters = q
+			ev.QueryStringParameters = make(map[string]string, len(q))
+			for k, vs := range q {
+				ev.QueryStringParameters[k] = vs[len(vs)-1]
+			}
+		}
+	}
+
+	// Headers replace ones of the event file with the same name (in any
+	// case), repeated flags of the same name add values.
+	h := make(http.Header)
+	for _, kv := range o.headers {
+		kv := strings.SplitN(kv, ":", 2)
+		h.Add(strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1]))
+	}
+	for k, vs := range h {
+		if ev.Headers == nil {
+			ev.Headers = make(map[string]string)
+		}
+		if ev.MultiValueHeaders == nil {
+			ev.MultiValueHeaders = make(map[string][]string)
+		}
+		for name := range ev.Headers {
+			if strings.EqualFold(name, k) {
+				delete(ev.Headers, name)
+			}
+		}
+		for name := range ev.MultiValueHeaders {
+			if strings.EqualFold(name, k) {
+				delete(ev.MultiValueHeaders, name)
+			}
+		}
+		ev.Headers[k] = vs[len(vs)-1]
+		ev.MultiValueHeaders[k] = vs
+	}
+
+	if o.body != "" {
+		body := []byte(o.body)
+		if strings.HasPrefix(o.body, "@") {
+			b, err := os.ReadFile(o.body[1:])
+			if err != nil {
+				return errors.Wrap(err, "reading body")
+			}
+			body = b
+		}
+
+		ev.IsBase64Encoded = !utf8.Valid(body)
+		if ev.IsBase64Encoded {
+			ev.Body = base64.StdEncoding.EncodeToString(body)
+		} else {
+			ev.Body = string(body)
+		}
+	}
+
+	return nil
+}
+
+// build returns an absolute path to the binary. Whether target is not an
+// executable file it is built with "go build" into a temporary directory.
+func build(target string) (string, func(), error) {
+	if fi, err := os.Stat(target); err == nil && fi.Mode().IsRegular() && fi.Mode()&0111 != 0 {
+		// Path is made absolute, otherwise exec.Command would look up
+		// a bare name (i.e. "handler") in the PATH.
+		bin, err := filepath.Abs(target)
+		if err != nil {
+			return "", nil, err
+		}
+		return bin, func() {}, nil
+	}
+
+	dir, err := os.MkdirTemp("", "apigo")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	bin := filepath.Join(dir, "handler")
+	cmd := exec.Command("go", "build", "-o", bin, target)
+	cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+	if err := cmd.Run(); err != nil {
+		cleanup()
+		return "", nil, errors.Wrapf(err, "building %s", target)
+	}
+	return bin, cleanup, nil
+}
+
+// invoke runs the binary against the Runtime API emulator and pushes the
+// event payload to it. Output of the binary is written to the standard error.
+func invoke(ctx context.Context, bin string, payload []byte) (runtimeapi.Result, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return runtimeapi.Result{}, err
+	}
+	defer l.Close()
+
+	s := runtimeapi.NewServer()
+	go http.Serve(l, s)
+
+	cmd := exec.Command(bin)
+	cmd.Env = append(os.Environ(), "AWS_LAMBDA_RUNTIME_API="+l.Addr().String())
+	cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+	if err := cmd.Start(); err != nil {
+		return runtimeapi.Result{}, errors.Wrap(err, "starting function")
+	}
+	defer cmd.Process.Kill()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+		cancel()
+	}()
+
+	res, err := s.Invoke(ctx, payload)
+	if err != nil {
+		select {
+		case werr := <-exited:
+			return res, errors.Errorf("function exited before responding: %v", werr)
+		default:
+		}
+	}
+	return res, err
+}
+
+// printResponse writes the response in a HTTP-like form with the decoded
+// body.
+func printResponse(w io.Writer, resp events.APIGatewayProxyResponse) error {
+	fmt.Fprintf(w, "HTTP %d %s\n", resp.StatusCode, http.StatusText(resp.StatusCode))
+
+	h := http.Header(resp.MultiValueHeaders).Clone()
+	if h == nil {
+		h = make(http.Header)
+	}
+	for k, v := range resp.Headers {
+		if _, ok := h[http.CanonicalHeaderKey(k)]; !ok {
+			h.Set(k, v)
+		}
+	}
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range h[k] {
+			fmt.Fprintf(w, "%s: %s\n", k, v)
+		}
+	}
+	fmt.Fprintln(w)
+
+	body := []byte(resp.Body)
+	if resp.IsBase64Encoded {
+		b, err := base64.StdEncoding.DecodeString(resp.Body)
+		if err != nil {
+			return errors.Wrap(err, "decoding base64 body")
+		}
+		body = b
+	}
+	_, err := w.Write(body)
+	if len(body) > 0 && body[len(body)-1] != '\n' {
+		fmt.Fprintln(w)
+	}
+	return err
+}
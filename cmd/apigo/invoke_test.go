@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverrides_apply(t *testing.T) {
+	ev := events.APIGatewayProxyRequest{
+		HTTPMethod: "GET",
+		Path:       "/pets",
+		Headers:    map[string]string{"Accept": "text/html"},
+	}
+
+	o := overrides{
+		method:  "post",
+		path:    "/pets/luna?fields=name&fields=species",
+		headers: headerFlags{"content-type: application/json"},
+		body:    `{"name":"Luna"}`,
+	}
+	assert.NoError(t, o.apply(&ev))
+
+	assert.Equal(t, "POST", ev.HTTPMethod)
+	assert.Equal(t, "/pets/luna", ev.Path)
+	assert.Equal(t, []string{"name", "species"}, ev.MultiValueQueryStringParameters["fields"])
+	assert.Equal(t, "species", ev.QueryStringParameters["fields"])
+	assert.Equal(t, "application/json", ev.Headers["Content-Type"])
+	assert.Equal(t, "text/html", ev.Headers["Accept"])
+	assert.Equal(t, []string{"application/json"}, ev.MultiValueHeaders["Content-Type"])
+	assert.Equal(t, `{"name":"Luna"}`, ev.Body)
+	assert.False(t, ev.IsBase64Encoded)
+
+	o = overrides{path: "/toys"}
+	ev.Resource = "/pets/{name}"
+	ev.PathParameters = map[string]string{"name": "luna"}
+	assert.NoError(t, o.apply(&ev))
+	assert.Equal(t, "/toys", ev.Path)
+	assert.Empty(t, ev.Resource)
+	assert.Nil(t, ev.PathParameters)
+	assert.Nil(t, ev.QueryStringParameters)
+	assert.Nil(t, ev.MultiValueQueryStringParameters)
+
+	ev.Headers = map[string]string{"accept": "text/html", "x-api-key": "abc"}
+	ev.MultiValueHeaders = map[string][]string{"accept": {"text/html"}, "x-api-key": {"abc"}}
+	o = overrides{headers: headerFlags{"Accept: application/json", "X-Tag: a", "x-tag: b"}}
+	assert.NoError(t, o.apply(&ev))
+	assert.Equal(t, map[string]string{"Accept": "application/json", "X-Tag": "b", "x-api-key": "abc"}, ev.Headers)
+	assert.Equal(t, map[string][]string{
+		"Accept":    {"application/json"},
+		"X-Tag":     {"a", "b"},
+		"x-api-key": {"abc"},
+	}, ev.MultiValueHeaders)
+
+	o = overrides{body: "\xff\xfe"}
+	assert.NoError(t, o.apply(&ev))
+	assert.Equal(t, "//4=", ev.Body)
+	assert.True(t, ev.IsBase64Encoded)
+}
+
+func TestHeaderFlags_Set(t *testing.T) {
+	var h headerFlags
+	assert.NoError(t, h.Set("X-Foo: bar"))
+	assert.Error(t, h.Set("X-Foo"))
+	assert.Equal(t, "X-Foo: bar", h.String())
+}
+
+func TestPrintResponse(t *testing.T) {
+	var buf bytes.Buffer
+	err := printResponse(&buf, events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers:    map[string]string{"Content-Type": "image/png"},
+		MultiValueHeaders: map[string][]string{
+			"Content-Type": {"image/png"},
+			"Set-Cookie":   {"a=1", "b=2"},
+		},
+		Body:            "ZGF0YQ==",
+		IsBase64Encoded: true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "HTTP 200 OK\nContent-Type: image/png\nSet-Cookie: a=1\nSet-Cookie: b=2\n\ndata\n", buf.String())
+}
+
+func TestBuild_binary(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "handler"), []byte("#!/bin/sh\n"), 0755))
+
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	bin, cleanup, err := build("handler")
+	assert.NoError(t, err)
+	defer cleanup()
+	assert.True(t, filepath.IsAbs(bin))
+	assert.Equal(t, "handler", filepath.Base(bin))
+}
@@ -0,0 +1,51 @@
+// Command apigo helps to develop AWS Lambda functions built with apigo
+// locally.
+//
+// Usage:
+//
+//	apigo invoke [flags] <package|binary> [event.json]
+//	apigo convert [flags] <file|->
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const usage = `Usage:
+
+	apigo <command> [arguments]
+
+Commands:
+
+	invoke    invoke a function with an API Gateway event
+	convert   convert a HAR archive or a curl command to API Gateway events
+
+Run "apigo <command> -h" for more information about a command.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "invoke":
+		err = runInvoke(os.Args[2:])
+	case "convert":
+		err = runConvert(os.Args[2:])
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(os.Stdout, usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "apigo: unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "apigo: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,181 @@
+// Package connections manages connections of the AWS API Gateway WebSocket
+// APIs via the @connections API, i.e. to send messages to the clients.
+package connections
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/piotrkubisa/apigo"
+	"github.com/pkg/errors"
+)
+
+// ErrGone is returned whether the connection does not exist anymore (i.e.
+// the client has disconnected).
+var ErrGone = errors.New("connection is gone")
+
+// API manages connections of the WebSocket API.
+type API interface {
+	// PostToConnection sends the data to the connected client.
+	PostToConnection(ctx context.Context, connectionID string, data []byte) error
+	// GetConnection returns information about the connection.
+	GetConnection(ctx context.Context, connectionID string) (Connection, error)
+	// DeleteConnection disconnects the client.
+	DeleteConnection(ctx context.Context, connectionID string) error
+}
+
+// Connection describes the connection of the client.
+type Connection struct {
+	ConnectedAt  time.Time `json:"connectedAt"`
+	LastActiveAt time.Time `json:"lastActiveAt"`
+	Identity     Identity  `json:"identity"`
+}
+
+// Identity describes the connected client.
+type Identity struct {
+	SourceIP  string `json:"sourceIp"`
+	UserAgent string `json:"userAgent"`
+}
+
+// Client calls the @connections API of the WebSocket API, requests are
+// signed with AWS Signature Version 4.
+type Client struct {
+	// Endpoint is a callback URL of the WebSocket API, i.e.
+	// "https://xxxxxxxxxx.execute-api.us-east-1.amazonaws.com/prod".
+	Endpoint    string
+	Region      string
+	Credentials Credentials
+	// HTTPClient is used to send requests, http.DefaultClient is used
+	// whether it is nil.
+	HTTPClient *http.Client
+}
+
+// NewClient creates new Client for the endpoint. Credentials are taken from
+// the environment variables (as provided to the AWS Lambda function) and
+// region is taken from the AWS_REGION variable or the endpoint's domain
+// name.
+func NewClient(endpoint string) *Client {
+	region := os.Getenv("AWS_REGION")
+	if u, err := url.Parse(endpoint); err == nil {
+		if r := domainRegion(u.Hostname()); r != "" {
+			region = r
+		}
+	}
+
+	return &Client{
+		Endpoint:    strings.TrimSuffix(endpoint, "/"),
+		Region:      region,
+		Credentials: EnvCredentials(),
+	}
+}
+
+// FromContext creates new Client for the WebSocket API which has sent the
+// event handled by the apigo.WebsocketGateway. The endpoint is derived from
+// the DomainName and the Stage of the event's Request Context.
+func FromContext(ctx context.Context) (*Client, bool) {
+	c, ok := apigo.WebsocketContext(ctx)
+	if !ok || c.DomainName == "" {
+		return nil, false
+	}
+	return NewClient("https://" + c.DomainName + "/" + c.Stage), true
+}
+
+// PostToConnection sends the data to the connected client.
+func (c *Client) PostToConnection(ctx context.Context, connectionID string, data []byte) error {
+	res, err := c.do(ctx, http.MethodPost, connectionID, data)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+// GetConnection returns information about the connection.
+func (c *Client) GetConnection(ctx context.Context, connectionID string) (Connection, error) {
+	var conn Connection
+
+	res, err := c.do(ctx, http.MethodGet, connectionID, nil)
+	if err != nil {
+		return conn, err
+	}
+	defer res.Body.Close()
+
+	if err := json.NewDecoder(res.Body).Decode(&conn); err != nil {
+		return conn, errors.Wrap(err, "decoding connection")
+	}
+	return conn, nil
+}
+
+// DeleteConnection disconnects the client.
+func (c *Client) DeleteConnection(ctx context.Context, connectionID string) error {
+	res, err := c.do(ctx, http.MethodDelete, connectionID, nil)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+// do sends a signed request to the connection's resource and returns
+// a successful response.
+func (c *Client) do(ctx context.Context, method, connectionID string, body []byte) (*http.Response, error) {
+	u, err := url.Parse(c.Endpoint + "/@connections/" + uriEscape(connectionID, true))
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing endpoint")
+	}
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+	sign(req, body, c.Credentials, c.Region, "execute-api", time.Now())
+
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	res, err := hc.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s connection %s", strings.ToLower(method), connectionID)
+	}
+	if res.StatusCode/100 == 2 {
+		return res, nil
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusGone {
+		return nil, ErrGone
+	}
+
+	var e struct {
+		Message string `json:"message"`
+	}
+	b, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+	if json.Unmarshal(b, &e) != nil || e.Message == "" {
+		e.Message = http.StatusText(res.StatusCode)
+	}
+	return nil, errors.Errorf("%s connection %s: %s (status %d)", strings.ToLower(method), connectionID, e.Message, res.StatusCode)
+}
+
+// domainRegion returns a region from the domain name of the API, i.e.
+// "xxxxxxxxxx.execute-api.us-east-1.amazonaws.com".
+func domainRegion(host string) string {
+	parts := strings.Split(host, ".")
+	for i := 0; i+1 < len(parts); i++ {
+		if parts[i] == "execute-api" {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
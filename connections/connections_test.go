@@ -0,0 +1,103 @@
+package connections
+
+import (
+	"io"
+
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/piotrkubisa/apigo"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	_ API = (*Client)(nil)
+	_ API = (*Fake)(nil)
+)
+
+func TestClient(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = append(got, r.Method+" "+r.URL.EscapedPath()+" "+string(body))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+		assert.Equal(t, "token", r.Header.Get("X-Amz-Security-Token"))
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/gone"):
+			w.WriteHeader(http.StatusGone)
+		case strings.HasSuffix(r.URL.Path, "/forbidden"):
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"Forbidden"}`))
+		case r.Method == http.MethodGet:
+			w.Write([]byte(`{"connectedAt":"2024-01-02T03:04:05.000Z","lastActiveAt":"2024-01-02T03:04:06.000Z","identity":{"sourceIp":"1.2.3.4","userAgent":"wscat"}}`))
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	c := &Client{
+		Endpoint:    srv.URL + "/prod",
+		Region:      "us-east-1",
+		Credentials: Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "token"},
+	}
+	ctx := context.TODO()
+
+	assert.NoError(t, c.PostToConnection(ctx, "L0SM9cOFvHcCIhw=", []byte("hello")))
+
+	conn, err := c.GetConnection(ctx, "L0SM9cOFvHcCIhw=")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3.4", conn.Identity.SourceIP)
+	assert.Equal(t, 2024, conn.ConnectedAt.Year())
+
+	assert.NoError(t, c.DeleteConnection(ctx, "L0SM9cOFvHcCIhw="))
+
+	assert.Equal(t, []string{
+		"POST /prod/@connections/L0SM9cOFvHcCIhw%3D hello",
+		"GET /prod/@connections/L0SM9cOFvHcCIhw%3D ",
+		"DELETE /prod/@connections/L0SM9cOFvHcCIhw%3D ",
+	}, got)
+
+	assert.Equal(t, ErrGone, c.PostToConnection(ctx, "gone", []byte("hello")))
+	assert.EqualError(t, c.DeleteConnection(ctx, "forbidden"), "delete connection forbidden: Forbidden (status 403)")
+}
+
+func TestFromContext(t *testing.T) {
+	_, ok := FromContext(context.TODO())
+	assert.False(t, ok)
+
+	ctx := apigo.NewWebsocketContext(context.TODO(), events.APIGatewayWebsocketProxyRequest{
+		RequestContext: events.APIGatewayWebsocketProxyRequestContext{
+			DomainName: "xxxxxxxxxx.execute-api.eu-west-1.amazonaws.com",
+			Stage:      "prod",
+		},
+	})
+	c, ok := FromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "https://xxxxxxxxxx.execute-api.eu-west-1.amazonaws.com/prod", c.Endpoint)
+	assert.Equal(t, "eu-west-1", c.Region)
+}
+
+func TestFake(t *testing.T) {
+	f := NewFake()
+	ctx := context.TODO()
+
+	f.Connect("abc", Identity{SourceIP: "1.2.3.4"})
+	assert.NoError(t, f.PostToConnection(ctx, "abc", []byte("hello")))
+	assert.Equal(t, [][]byte{[]byte("hello")}, f.Messages("abc"))
+
+	conn, err := f.GetConnection(ctx, "abc")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3.4", conn.Identity.SourceIP)
+
+	assert.NoError(t, f.DeleteConnection(ctx, "abc"))
+	assert.Equal(t, ErrGone, f.PostToConnection(ctx, "abc", []byte("hello")))
+	_, err = f.GetConnection(ctx, "abc")
+	assert.Equal(t, ErrGone, err)
+	assert.Equal(t, ErrGone, f.DeleteConnection(ctx, "abc"))
+}
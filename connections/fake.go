@@ -0,0 +1,86 @@
+package connections
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Fake is an in-memory implementation of the API, i.e. for tests or local
+// development. Connections are added with Connect and messages sent to them
+// are obtained with Messages.
+type Fake struct {
+	mu    sync.Mutex
+	conns map[string]*fakeConnection
+}
+
+type fakeConnection struct {
+	info     Connection
+	messages [][]byte
+}
+
+// NewFake creates new Fake without any connections.
+func NewFake() *Fake {
+	return &Fake{conns: make(map[string]*fakeConnection)}
+}
+
+// Connect adds the connection of the client.
+func (f *Fake) Connect(connectionID string, identity Identity) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now().UTC()
+	f.conns[connectionID] = &fakeConnection{
+		info: Connection{ConnectedAt: now, LastActiveAt: now, Identity: identity},
+	}
+}
+
+// Messages returns messages sent to the connection.
+func (f *Fake) Messages(connectionID string) [][]byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	conn, ok := f.conns[connectionID]
+	if !ok {
+		return nil
+	}
+	return append([][]byte(nil), conn.messages...)
+}
+
+// PostToConnection stores the data as a message sent to the connection.
+func (f *Fake) PostToConnection(ctx context.Context, connectionID string, data []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	conn, ok := f.conns[connectionID]
+	if !ok {
+		return ErrGone
+	}
+	conn.messages = append(conn.messages, append([]byte(nil), data...))
+	conn.info.LastActiveAt = time.Now().UTC()
+	return nil
+}
+
+// GetConnection returns information about the connection.
+func (f *Fake) GetConnection(ctx context.Context, connectionID string) (Connection, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	conn, ok := f.conns[connectionID]
+	if !ok {
+		return Connection{}, ErrGone
+	}
+	return conn.info, nil
+}
+
+// DeleteConnection removes the connection.
+func (f *Fake) DeleteConnection(ctx context.Context, connectionID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.conns[connectionID]; !ok {
+		return ErrGone
+	}
+	delete(f.conns, connectionID)
+	return nil
+}
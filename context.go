@@ -10,6 +10,30 @@ type requestContextKey struct{}
 
 var contextKey = &requestContextKey{}
 
+type basePathContextKey struct{}
+
+var basePathKey = &basePathContextKey{}
+
+type clientIPContextKey struct{}
+
+var clientIPKey = &clientIPContextKey{}
+
+type principalContextKey struct{}
+
+var principalKey = &principalContextKey{}
+
+type traceContextKey struct{}
+
+var traceKey = &traceContextKey{}
+
+type coldStartContextKey struct{}
+
+var coldStartKey = &coldStartContextKey{}
+
+type websocketContextKey struct{}
+
+var websocketKey = &websocketContextKey{}
+
 // NewContext populates a context.Context from the http.Request with a
 // request context provided in event from the AWS API Gateway proxy.
 func NewContext(ctx context.Context, ev events.APIGatewayProxyRequest) context.Context {
@@ -21,3 +45,60 @@ func RequestContext(ctx context.Context) (events.APIGatewayProxyRequestContext,
 	c, ok := ctx.Value(contextKey).(events.APIGatewayProxyRequestContext)
 	return c, ok
 }
+
+// ColdStart reports whether the invocation is the first one handled by the
+// Gateway in the execution environment. Warm-up events count as
+// invocations, so the request which follows a warm-up is not a cold start.
+func ColdStart(ctx context.Context) bool {
+	cold, _ := ctx.Value(coldStartKey).(bool)
+	return cold
+}
+
+// BasePath returns a base path which has been stripped out from the path
+// of the http.Request.
+func BasePath(ctx context.Context) (string, bool) {
+	p, ok := ctx.Value(basePathKey).(string)
+	return p, ok
+}
+
+// ClientIP returns an IP address of the client, which has been resolved from
+// the event by a ClientIPResolver.
+func ClientIP(ctx context.Context) (string, bool) {
+	ip, ok := ctx.Value(clientIPKey).(string)
+	return ip, ok
+}
+
+// Principal returns a principal mapped from the verified client certificate
+// by ClientCertVerifier.
+func Principal(ctx context.Context) (string, bool) {
+	p, ok := ctx.Value(principalKey).(string)
+	return p, ok
+}
+
+// Trace returns the TraceContext of the request stored in ctx.
+func Trace(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceKey).(TraceContext)
+	return tc, ok
+}
+
+// NewWebsocketContext populates a context.Context with a request context
+// provided in event from the AWS API Gateway WebSocket API.
+func NewWebsocketContext(ctx context.Context, ev events.APIGatewayWebsocketProxyRequest) context.Context {
+	return context.WithValue(ctx, websocketKey, ev.RequestContext)
+}
+
+// WebsocketContext returns the APIGatewayWebsocketProxyRequestContext value
+// stored in ctx.
+func WebsocketContext(ctx context.Context) (events.APIGatewayWebsocketProxyRequestContext, bool) {
+	c, ok := ctx.Value(websocketKey).(events.APIGatewayWebsocketProxyRequestContext)
+	return c, ok
+}
+
+// ConnectionID returns an ID of the WebSocket connection stored in ctx.
+func ConnectionID(ctx context.Context) (string, bool) {
+	c, ok := WebsocketContext(ctx)
+	if !ok || c.ConnectionID == "" {
+		return "", false
+	}
+	return c.ConnectionID, true
+}
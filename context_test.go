@@ -2,6 +2,7 @@ package apigo
 
 import (
 	"context"
+	"net/http"
 	"testing"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -58,3 +59,17 @@ func TestNewRequest_context(t *testing.T) {
 	v := r.Context().Value(testContextKey)
 	assert.Equal(t, "value", v)
 }
+
+func TestColdStart(t *testing.T) {
+	var cold []bool
+	g := NewGateway("api.example.com", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cold = append(cold, ColdStart(r.Context()))
+	}))
+
+	for i := 0; i < 2; i++ {
+		_, err := g.Serve(context.TODO(), events.APIGatewayProxyRequest{Path: "/"})
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []bool{true, false}, cold)
+	assert.False(t, ColdStart(context.TODO()))
+}
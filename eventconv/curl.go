@@ -0,0 +1,326 @@
+package eventconv
+
+import (
+	"os"
+
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// curlIgnoredFlags are curl flags without a value, which do not affect the
+// request sent to the server.
+var curlIgnoredFlags = map[string]bool{
+	"-s": true, "--silent": true, "-S": true, "--show-error": true,
+	"-k": true, "--insecure": true, "-L": true, "--location": true,
+	"-i": true, "--include": true, "-v": true, "--verbose": true,
+	"-f": true, "--fail": true, "-g": true, "--globoff": true,
+	"--compressed": true, "--http1.1": true, "--http2": true,
+	"-N": true, "--no-buffer": true,
+}
+
+// curlIgnoredOptions are curl flags with a value, which do not affect the
+// request sent to the server.
+var curlIgnoredOptions = map[string]bool{
+	"-o": true, "--output": true, "-m": true, "--max-time": true,
+	"--connect-timeout": true, "-w": true, "--write-out": true,
+	"--retry": true, "-x": true, "--proxy": true, "--cacert": true,
+	"-E": true, "--cert": true, "--key": true, "--resolve": true,
+}
+
+// ParseCurl parses the curl command line (i.e. copied from the browser's
+// developer tools) into the http.Request.
+func ParseCurl(cmdline string) (*http.Request, error) {
+	args, err := splitShell(cmdline)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 || args[0] != "curl" {
+		return nil, errors.New("command must start with curl")
+	}
+
+	var (
+		method, rawURL string
+		data           []string
+		get, head      bool
+		header         = make(http.Header)
+	)
+
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		name, value, hasValue := arg, "", false
+
+		// Value attached to the short flag, i.e. -XPOST.
+		if len(arg) > 2 && arg[0] == '-' && arg[1] != '-' && strings.ContainsRune("XHdbuAe", rune(arg[1])) {
+			name, value, hasValue = arg[:2], arg[2:], true
+		}
+		if strings.HasPrefix(arg, "--") && strings.Contains(arg, "=") {
+			kv := strings.SplitN(arg, "=", 2)
+			name, value, hasValue = kv[0], kv[1], true
+		}
+
+		optValue := func() (string, error) {
+			if hasValue {
+				return value, nil
+			}
+			if i+1 >= len(args) {
+				return "", errors.Errorf("missing value of %s", name)
+			}
+			i++
+			return args[i], nil
+		}
+
+		switch {
+		case !strings.HasPrefix(arg, "-"):
+			rawURL = arg
+			continue
+		case curlIgnoredFlags[name]:
+			continue
+		case curlIgnoredOptions[name]:
+			if _, err := optValue(); err != nil {
+				return nil, err
+			}
+			continue
+		case name == "-G" || name == "--get":
+			get = true
+			continue
+		case name == "-I" || name == "--head":
+			head = true
+			continue
+		case isShortFlags(arg):
+			continue
+		}
+
+		v, err := optValue()
+		if err != nil {
+			return nil, err
+		}
+
+		switch name {
+		case "-X", "--request":
+			method = strings.ToUpper(v)
+		case "--url":
+			rawURL = v
+		case "-H", "--header":
+			kv := strings.SplitN(v, ":", 2)
+			if len(kv) != 2 {
+				return nil, errors.Errorf("invalid header %q", v)
+			}
+			header.Add(strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1]))
+		case "-d", "--data", "--data-ascii", "--data-binary":
+			if strings.HasPrefix(v, "@") {
+				b, err := os.ReadFile(v[1:])
+				if err != nil {
+					return nil, errors.Wrap(err, "reading data")
+				}
+				v = string(b)
+				if name != "--data-binary" {
+					v = strings.NewReplacer("\r", "", "\n", "").Replace(v)
+				}
+			}
+			data = append(data, v)
+		case "--data-raw":
+			data = append(data, v)
+		case "--data-urlencode":
+			data = append(data, urlencodeData(v))
+		case "--json":
+			data = append(data, v)
+			if header.Get("Content-Type") == "" {
+				header.Set("Content-Type", "application/json")
+			}
+			if header.Get("Accept") == "" {
+				header.Set("Accept", "application/json")
+			}
+		case "-b", "--cookie":
+			header.Add("Cookie", v)
+		case "-u", "--user":
+			header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(v)))
+		case "-A", "--user-agent":
+			header.Set("User-Agent", v)
+		case "-e", "--referer":
+			header.Set("Referer", v)
+		default:
+			return nil, errors.Errorf("unsupported curl option %s", name)
+		}
+	}
+
+	if rawURL == "" {
+		return nil, errors.New("missing URL")
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing URL")
+	}
+
+	body := strings.Join(data, "&")
+	switch {
+	case head:
+		method, body = http.MethodHead, ""
+	case get:
+		if body != "" {
+			if u.RawQuery != "" {
+				u.RawQuery += "&"
+			}
+			u.RawQuery += body
+		}
+		method, body = http.MethodGet, ""
+	case method == "" && len(data) > 0:
+		method = http.MethodPost
+	case method == "":
+		method = http.MethodGet
+	}
+
+	r, err := http.NewRequest(method, u.String(), strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body == "" {
+		r.Body = nil
+	}
+	r.Header = header
+	if len(data) > 0 && body != "" && header.Get("Content-Type") == "" {
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if h := header.Get("Host"); h != "" {
+		r.Host = h
+	}
+	return r, nil
+}
+
+// isShortFlags reports whether arg is a group of ignored short flags
+// (i.e. -sSL).
+func isShortFlags(arg string) bool {
+	if len(arg) < 3 || arg[0] != '-' || arg[1] == '-' {
+		return false
+	}
+	for _, c := range arg[1:] {
+		if !curlIgnoredFlags["-"+string(c)] {
+			return false
+		}
+	}
+	return true
+}
+
+// urlencodeData encodes a value of the --data-urlencode option.
+func urlencodeData(v string) string {
+	if i := strings.Index(v, "="); i > 0 {
+		return v[:i] + "=" + url.QueryEscape(v[i+1:])
+	}
+	return url.QueryEscape(strings.TrimPrefix(v, "="))
+}
+
+// splitShell splits the command line into arguments following the POSIX
+// shell quoting rules (including $'...' strings and line continuations).
+func splitShell(s string) ([]string, error) {
+	var (
+		args    []string
+		cur     strings.Builder
+		inArg   bool
+		escaped bool
+	)
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		if escaped {
+			if c != '\n' {
+				cur.WriteByte(c)
+				inArg = true
+			}
+			escaped = false
+			continue
+		}
+
+		switch {
+		case c == '\\':
+			escaped = true
+		case c == '\'':
+			j := strings.IndexByte(s[i+1:], '\'')
+			if j < 0 {
+				return nil, errors.New("unterminated single quote")
+			}
+			cur.WriteString(s[i+1 : i+1+j])
+			i += j + 1
+			inArg = true
+		case c == '$' && i+1 < len(s) && s[i+1] == '\'':
+			n, err := ansiCString(s[i+2:], &cur)
+			if err != nil {
+				return nil, err
+			}
+			i += n + 1
+			inArg = true
+		case c == '"':
+			n, err := doubleQuoted(s[i+1:], &cur)
+			if err != nil {
+				return nil, err
+			}
+			i += n
+			inArg = true
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteByte(c)
+			inArg = true
+		}
+	}
+
+	if escaped {
+		return nil, errors.New("trailing backslash")
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
+
+// doubleQuoted writes the content of a double-quoted string to b and
+// returns the number of consumed bytes (including the closing quote).
+func doubleQuoted(s string, b *strings.Builder) (int, error) {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			return i + 1, nil
+		case c == '\\' && i+1 < len(s) && strings.IndexByte("\"\\$`\n", s[i+1]) >= 0:
+			if s[i+1] != '\n' {
+				b.WriteByte(s[i+1])
+			}
+			i++
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return 0, errors.New("unterminated double quote")
+}
+
+// ansiCString writes the content of a $'...' string to b and returns the
+// number of consumed bytes (including the closing quote).
+func ansiCString(s string, b *strings.Builder) (int, error) {
+	escapes := map[byte]byte{'n': '\n', 't': '\t', 'r': '\r', '\\': '\\', '\'': '\'', '"': '"', '0': 0}
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\'':
+			return i + 1, nil
+		case c == '\\' && i+1 < len(s):
+			if e, ok := escapes[s[i+1]]; ok {
+				b.WriteByte(e)
+			} else {
+				b.WriteByte(c)
+				b.WriteByte(s[i+1])
+			}
+			i++
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return 0, errors.New("unterminated $' quote")
+}
@@ -0,0 +1,72 @@
+package eventconv
+
+import (
+	"io"
+
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitShell(t *testing.T) {
+	args, err := splitShell(`curl 'https://api.example.com/pets' \
+  -H "X-Quote: \"a\" \$b" --data-raw $'{"name":"Luna\'s"}\n' plain\ arg`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"curl",
+		"https://api.example.com/pets",
+		"-H", `X-Quote: "a" $b`,
+		"--data-raw", "{\"name\":\"Luna's\"}\n",
+		"plain arg",
+	}, args)
+
+	_, err = splitShell(`curl 'unterminated`)
+	assert.Error(t, err)
+}
+
+func TestParseCurl(t *testing.T) {
+	r, err := ParseCurl(`curl -sSL -XPUT 'https://api.example.com/pets/luna?fields=name' \
+		-H 'Content-Type: application/json' -H 'Accept: application/json' \
+		-b 'session=abc' -u 'john:secret' --compressed \
+		--data-raw '{"name":"Luna"}'`)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "PUT", r.Method)
+	assert.Equal(t, "https://api.example.com/pets/luna?fields=name", r.URL.String())
+	assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+	assert.Equal(t, "session=abc", r.Header.Get("Cookie"))
+	assert.Equal(t, "Basic am9objpzZWNyZXQ=", r.Header.Get("Authorization"))
+
+	b, err := io.ReadAll(r.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"Luna"}`, string(b))
+}
+
+func TestParseCurl_data(t *testing.T) {
+	r, err := ParseCurl(`curl api.example.com/pets -d name=Luna --data-urlencode 'species=cat & dog'`)
+	assert.NoError(t, err)
+	assert.Equal(t, "POST", r.Method)
+	assert.Equal(t, "http://api.example.com/pets", r.URL.String())
+	assert.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+
+	b, _ := io.ReadAll(r.Body)
+	assert.Equal(t, "name=Luna&species=cat+%26+dog", string(b))
+
+	r, err = ParseCurl(`curl -G https://api.example.com/pets?order=desc -d limit=10`)
+	assert.NoError(t, err)
+	assert.Equal(t, "GET", r.Method)
+	assert.Equal(t, "order=desc&limit=10", r.URL.RawQuery)
+	assert.Nil(t, r.Body)
+}
+
+func TestParseCurl_invalid(t *testing.T) {
+	for _, cmd := range []string{
+		`wget https://api.example.com`,
+		`curl -H`,
+		`curl -H 'Content-Type: application/json'`,
+		`curl --unknown https://api.example.com`,
+	} {
+		_, err := ParseCurl(cmd)
+		assert.Error(t, err, cmd)
+	}
+}
@@ -0,0 +1,207 @@
+// Package eventconv converts HTTP traffic (http.Request, curl command lines
+// and HAR archives) into API Gateway proxy events, so real requests can be
+// reproduced locally with apigo.Gateway.Serve.
+package eventconv
+
+import (
+	"io"
+
+	"bytes"
+	"encoding/base64"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/pkg/errors"
+)
+
+// FromRequest converts the http.Request to an API Gateway (REST API) proxy
+// event. Body is base64 encoded whether it does not represent text.
+func FromRequest(r *http.Request) (events.APIGatewayProxyRequest, error) {
+	body, binary, err := readBody(r)
+	if err != nil {
+		return events.APIGatewayProxyRequest{}, err
+	}
+
+	h := requestHeaders(r)
+	ev := events.APIGatewayProxyRequest{
+		Path:              r.URL.Path,
+		HTTPMethod:        r.Method,
+		Headers:           make(map[string]string, len(h)),
+		MultiValueHeaders: make(map[string][]string, len(h)),
+		Body:              body,
+		IsBase64Encoded:   binary,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			HTTPMethod: r.Method,
+			Path:       r.URL.EscapedPath(),
+			DomainName: r.URL.Host,
+			Protocol:   protocol(r),
+			Identity: events.APIGatewayRequestIdentity{
+				UserAgent: h.Get("User-Agent"),
+			},
+		},
+	}
+	if ev.Path == "" {
+		ev.Path = "/"
+		ev.RequestContext.Path = "/"
+	}
+
+	for k, vs := range h {
+		ev.Headers[k] = vs[len(vs)-1]
+		ev.MultiValueHeaders[k] = vs
+	}
+
+	if q := r.URL.Query(); len(q) > 0 {
+		ev.QueryStringParameters = make(map[string]string, len(q))
+		ev.MultiValueQueryStringParameters = make(map[string][]string, len(q))
+		for k, vs := range q {
+			ev.QueryStringParameters[k] = vs[len(vs)-1]
+			ev.MultiValueQueryStringParameters[k] = vs
+		}
+	}
+
+	return ev, nil
+}
+
+// FromRequestV2 converts the http.Request to an API Gateway HTTP API
+// (payload format version 2.0) event.
+func FromRequestV2(r *http.Request) (events.APIGatewayV2HTTPRequest, error) {
+	body, binary, err := readBody(r)
+	if err != nil {
+		return events.APIGatewayV2HTTPRequest{}, err
+	}
+
+	path := r.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	h := requestHeaders(r)
+	ev := events.APIGatewayV2HTTPRequest{
+		Version:         "2.0",
+		RouteKey:        "$default",
+		RawPath:         path,
+		RawQueryString:  r.URL.RawQuery,
+		Headers:         make(map[string]string, len(h)),
+		Body:            body,
+		IsBase64Encoded: binary,
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			RouteKey:   "$default",
+			Stage:      "$default",
+			DomainName: r.URL.Host,
+			HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{
+				Method:    r.Method,
+				Path:      path,
+				Protocol:  protocol(r),
+				UserAgent: h.Get("User-Agent"),
+			},
+		},
+	}
+
+	for k, vs := range h {
+		if k == "Cookie" {
+			for _, v := range vs {
+				for _, c := range strings.Split(v, ";") {
+					if c = strings.TrimSpace(c); c != "" {
+						ev.Cookies = append(ev.Cookies, c)
+					}
+				}
+			}
+			continue
+		}
+		ev.Headers[strings.ToLower(k)] = strings.Join(vs, ",")
+	}
+
+	if q := r.URL.Query(); len(q) > 0 {
+		ev.QueryStringParameters = make(map[string]string, len(q))
+		for k, vs := range q {
+			ev.QueryStringParameters[k] = strings.Join(vs, ",")
+		}
+	}
+
+	return ev, nil
+}
+
+// requestHeaders returns headers of the request with the Host and
+// X-Forwarded-Proto headers, as they are provided by the API Gateway.
+func requestHeaders(r *http.Request) http.Header {
+	h := r.Header.Clone()
+	if h == nil {
+		h = make(http.Header)
+	}
+
+	host := r.Host
+	if host == "" {
+		host = r.URL.Host
+	}
+	if host != "" {
+		h.Set("Host", host)
+	}
+	if r.URL.Scheme != "" && h.Get("X-Forwarded-Proto") == "" {
+		h.Set("X-Forwarded-Proto", r.URL.Scheme)
+	}
+	return h
+}
+
+// readBody returns the body of the request, which is base64 encoded whether
+// it does not represent text.
+func readBody(r *http.Request) (string, bool, error) {
+	if r.Body == nil {
+		return "", false, nil
+	}
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return "", false, errors.Wrap(err, "reading body")
+	}
+	r.Body = io.NopCloser(bytes.NewReader(b))
+
+	if len(b) == 0 {
+		return "", false, nil
+	}
+	if utf8.Valid(b) && isText(r.Header.Get("Content-Type")) {
+		return string(b), false, nil
+	}
+	return base64.StdEncoding.EncodeToString(b), true, nil
+}
+
+// isText reports whether the content type represents textual data, empty
+// content type is considered as a text.
+func isText(kind string) bool {
+	if kind == "" {
+		return true
+	}
+
+	mt, _, err := mime.ParseMediaType(kind)
+	if err != nil {
+		return false
+	}
+
+	switch {
+	case strings.HasPrefix(mt, "text/"),
+		strings.HasSuffix(mt, "+json"),
+		strings.HasSuffix(mt, "+xml"):
+		return true
+	}
+
+	switch mt {
+	case "application/json",
+		"application/xml",
+		"application/javascript",
+		"application/x-www-form-urlencoded",
+		"application/graphql":
+		return true
+	default:
+		return false
+	}
+}
+
+// protocol returns the HTTP protocol version of the request.
+func protocol(r *http.Request) string {
+	if r.Proto == "" {
+		return "HTTP/1.1"
+	}
+	return r.Proto
+}
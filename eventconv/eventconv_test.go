@@ -0,0 +1,80 @@
+package eventconv
+
+import (
+	"io"
+
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/piotrkubisa/apigo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromRequest(t *testing.T) {
+	r, err := http.NewRequest("POST", "https://api.example.com/pets/a%2Fb?tag=a&tag=b", strings.NewReader(`{"name":"Luna"}`))
+	assert.NoError(t, err)
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Add("Accept", "text/html")
+	r.Header.Add("Accept", "application/json")
+
+	ev, err := FromRequest(r)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "POST", ev.HTTPMethod)
+	assert.Equal(t, "/pets/a/b", ev.Path)
+	assert.Equal(t, "/pets/a%2Fb", ev.RequestContext.Path)
+	assert.Equal(t, "api.example.com", ev.Headers["Host"])
+	assert.Equal(t, "https", ev.Headers["X-Forwarded-Proto"])
+	assert.Equal(t, []string{"text/html", "application/json"}, ev.MultiValueHeaders["Accept"])
+	assert.Equal(t, "application/json", ev.Headers["Accept"])
+	assert.Equal(t, []string{"a", "b"}, ev.MultiValueQueryStringParameters["tag"])
+	assert.Equal(t, "b", ev.QueryStringParameters["tag"])
+	assert.Equal(t, `{"name":"Luna"}`, ev.Body)
+	assert.False(t, ev.IsBase64Encoded)
+}
+
+func TestFromRequest_binary(t *testing.T) {
+	r, err := http.NewRequest("POST", "https://api.example.com/photo", strings.NewReader("data"))
+	assert.NoError(t, err)
+	r.Header.Set("Content-Type", "image/png")
+
+	ev, err := FromRequest(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "ZGF0YQ==", ev.Body)
+	assert.True(t, ev.IsBase64Encoded)
+}
+
+func TestFromRequestV2(t *testing.T) {
+	r, err := ParseCurl(`curl 'https://api.example.com/pets?tag=a&tag=b' -H 'Cookie: a=1; b=2' -H 'X-Foo: bar'`)
+	assert.NoError(t, err)
+
+	ev, err := FromRequestV2(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "2.0", ev.Version)
+	assert.Equal(t, "/pets", ev.RawPath)
+	assert.Equal(t, "tag=a&tag=b", ev.RawQueryString)
+	assert.Equal(t, "a,b", ev.QueryStringParameters["tag"])
+	assert.Equal(t, []string{"a=1", "b=2"}, ev.Cookies)
+	assert.Equal(t, "bar", ev.Headers["x-foo"])
+	assert.Equal(t, "GET", ev.RequestContext.HTTP.Method)
+}
+
+func TestFromRequest_serve(t *testing.T) {
+	r, err := ParseCurl(`curl -X PATCH 'https://api.example.com/pets/luna?fields=name' -H 'X-Foo: bar' -d 'name=Luna'`)
+	assert.NoError(t, err)
+
+	ev, err := FromRequest(r)
+	assert.NoError(t, err)
+
+	g := apigo.NewGateway("", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		w.Write([]byte(r.Method + " " + r.Host + r.URL.RequestURI() + " " + r.Header.Get("X-Foo") + " " + string(b)))
+	}))
+	g.Proxy = &apigo.DefaultProxy{HostResolver: apigo.EventHost}
+
+	resp, err := g.Serve(context.TODO(), ev)
+	assert.NoError(t, err)
+	assert.Equal(t, "PATCH api.example.com/pets/luna?fields=name bar name=Luna", resp.Body)
+}
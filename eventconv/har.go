@@ -0,0 +1,98 @@
+package eventconv
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// harArchive is a subset of the HTTP Archive (HAR) 1.2 format describing
+// requests.
+type harArchive struct {
+	Log struct {
+		Entries []struct {
+			Request harRequest `json:"request"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// harRequest is a request of the HAR entry.
+type harRequest struct {
+	Method      string `json:"method"`
+	URL         string `json:"url"`
+	HTTPVersion string `json:"httpVersion"`
+	Headers     []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"headers"`
+	PostData *struct {
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+		Encoding string `json:"encoding"`
+	} `json:"postData"`
+}
+
+// ParseHAR parses requests of all entries from the HAR archive.
+func ParseHAR(r io.Reader) ([]*http.Request, error) {
+	var har harArchive
+	if err := json.NewDecoder(r).Decode(&har); err != nil {
+		return nil, errors.Wrap(err, "decoding HAR")
+	}
+
+	reqs := make([]*http.Request, 0, len(har.Log.Entries))
+	for i, e := range har.Log.Entries {
+		req, err := e.Request.httpRequest()
+		if err != nil {
+			return nil, errors.Wrapf(err, "entry %d", i)
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs, nil
+}
+
+// httpRequest converts the HAR request to the http.Request.
+func (hr harRequest) httpRequest() (*http.Request, error) {
+	var body io.Reader
+	if pd := hr.PostData; pd != nil && pd.Text != "" {
+		text := pd.Text
+		if pd.Encoding == "base64" {
+			b, err := base64.StdEncoding.DecodeString(text)
+			if err != nil {
+				return nil, errors.Wrap(err, "decoding postData")
+			}
+			text = string(b)
+		}
+		body = strings.NewReader(text)
+	}
+
+	req, err := http.NewRequest(hr.Method, hr.URL, body)
+	if err != nil {
+		return nil, err
+	}
+	switch v := strings.ToUpper(hr.HTTPVersion); v {
+	case "":
+	case "H2", "HTTP/2":
+		req.Proto = "HTTP/2.0"
+	default:
+		req.Proto = v
+	}
+
+	for _, h := range hr.Headers {
+		// HTTP/2 pseudo-headers (i.e. ":authority") are not sent as headers.
+		if strings.HasPrefix(h.Name, ":") {
+			continue
+		}
+		req.Header.Add(h.Name, h.Value)
+	}
+	if pd := hr.PostData; pd != nil && pd.MimeType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", pd.MimeType)
+	}
+	if h := req.Header.Get("Host"); h != "" {
+		req.Host = h
+	}
+	return req, nil
+}
@@ -0,0 +1,64 @@
+package eventconv
+
+import (
+	"io"
+
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testHAR = `{
+  "log": {
+    "version": "1.2",
+    "entries": [
+      {
+        "request": {
+          "method": "GET",
+          "url": "https://api.example.com/pets?order=desc",
+          "httpVersion": "h2",
+          "headers": [
+            {"name": ":authority", "value": "api.example.com"},
+            {"name": "accept", "value": "application/json"},
+            {"name": "x-multi", "value": "a"},
+            {"name": "x-multi", "value": "b"}
+          ]
+        }
+      },
+      {
+        "request": {
+          "method": "POST",
+          "url": "https://api.example.com/pets/luna/photo",
+          "httpVersion": "HTTP/1.1",
+          "headers": [],
+          "postData": {"mimeType": "image/png", "text": "ZGF0YQ==", "encoding": "base64"}
+        }
+      }
+    ]
+  }
+}`
+
+func TestParseHAR(t *testing.T) {
+	reqs, err := ParseHAR(strings.NewReader(testHAR))
+	assert.NoError(t, err)
+	if !assert.Len(t, reqs, 2) {
+		return
+	}
+
+	r := reqs[0]
+	assert.Equal(t, "GET", r.Method)
+	assert.Equal(t, "HTTP/2.0", r.Proto)
+	assert.Equal(t, "desc", r.URL.Query().Get("order"))
+	assert.Equal(t, []string{"a", "b"}, r.Header["X-Multi"])
+	assert.Empty(t, r.Header.Get(":authority"))
+
+	r = reqs[1]
+	assert.Equal(t, "POST", r.Method)
+	assert.Equal(t, "image/png", r.Header.Get("Content-Type"))
+	b, _ := io.ReadAll(r.Body)
+	assert.Equal(t, "data", string(b))
+
+	_, err = ParseHAR(strings.NewReader(`{"log": `))
+	assert.Error(t, err)
+}
@@ -0,0 +1,130 @@
+package apigo
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func FuzzOmitBasePath(f *testing.F) {
+	f.Add("/v1/pets", "v1")
+	f.Add("/v1", "/v1/")
+	f.Add("/v1//pets", "v1")
+	f.Add("/v10/pets", "v1")
+	f.Add("/", "")
+	f.Add("//", "/")
+
+	f.Fuzz(func(t *testing.T, path, basePath string) {
+		got := omitBasePath(path, basePath)
+		if got != "/" && !strings.HasSuffix(path, got) {
+			t.Fatalf("omitBasePath(%q, %q) = %q is not a suffix of the path", path, basePath, got)
+		}
+		if strings.HasPrefix(path, "/") && !strings.HasPrefix(got, "/") {
+			t.Fatalf("omitBasePath(%q, %q) = %q does not start with /", path, basePath, got)
+		}
+	})
+}
+
+func FuzzParseBody(f *testing.F) {
+	f.Add("hello", false)
+	f.Add("aGVsbG8=", true)
+	f.Add("%", true)
+	f.Add("", true)
+
+	f.Fuzz(func(t *testing.T, body string, isBase64 bool) {
+		r := NewRequest(context.TODO(), events.APIGatewayProxyRequest{Body: body, IsBase64Encoded: isBase64})
+		if err := r.ParseBody(); err != nil {
+			if !isBase64 {
+				t.Fatalf("ParseBody(%q) failed: %v", body, err)
+			}
+			return
+		}
+
+		want := []byte(body)
+		if isBase64 {
+			want, _ = base64.StdEncoding.DecodeString(body)
+		}
+		got, _ := io.ReadAll(r.Body)
+		if !bytes.Equal(want, got) {
+			t.Fatalf("ParseBody(%q) = %q, want %q", body, got, want)
+		}
+	})
+}
+
+func FuzzTransform(f *testing.F) {
+	f.Add("/v1/pets/1", "v1", "/pets/{id}", "id", "1", "Content-Type", "application/json", "tag", "a b", `{"name":"Luna"}`, false)
+	f.Add("/prod/files/a b", "", "/files/{proxy+}", "proxy", "a b", "X-Forwarded-For", "1.2.3.4, 10.0.0.1", "q", "%2F", "aGVsbG8=", true)
+	f.Add("/", "/", "/", "", "", "Host", "api.example.com", "", "", "", false)
+	f.Add("//a/../b", "a", "/{x}", "x", "../b", "traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", "a=b", "c", "%", true)
+
+	f.Fuzz(func(t *testing.T, path, basePath, resource, param, value, header, headerValue, key, query, body string, isBase64 bool) {
+		ev := events.APIGatewayProxyRequest{
+			HTTPMethod:                      "POST",
+			Path:                            path,
+			Resource:                        resource,
+			PathParameters:                  map[string]string{param: value},
+			Headers:                         map[string]string{header: headerValue},
+			MultiValueHeaders:               map[string][]string{header: {headerValue, headerValue}},
+			QueryStringParameters:           map[string]string{key: query},
+			MultiValueQueryStringParameters: map[string][]string{key: {query, query}},
+			Body:                            body,
+			IsBase64Encoded:                 isBase64,
+			RequestContext: events.APIGatewayProxyRequestContext{
+				Stage: basePath,
+				Path:  path,
+			},
+		}
+
+		proxies := []Proxy{
+			&DefaultProxy{Host: "api.example.com", HostResolver: EventHost},
+			&StripBasePathProxy{BasePath: basePath},
+			&DetectBasePathProxy{Host: "api.example.com"},
+		}
+		for _, p := range proxies {
+			r, err := p.Transform(context.TODO(), ev)
+			if err != nil {
+				continue
+			}
+			if strings.HasPrefix(path, "/") && !strings.HasPrefix(r.URL.Path, "/") {
+				t.Fatalf("%T.Transform(%q) URL.Path = %q does not start with /", p, path, r.URL.Path)
+			}
+			if want := resolveHost(hostResolver(p), proxyHost(p), ev); r.URL.Host != want {
+				t.Fatalf("%T.Transform(%q) URL.Host = %q, want %q", p, path, r.URL.Host, want)
+			}
+			if r.Header.Get("Host") != "" {
+				t.Fatalf("%T.Transform(%q) has left Host header", p, path)
+			}
+		}
+	})
+}
+
+// proxyHost returns the static host of the proxy.
+func proxyHost(p Proxy) string {
+	switch p := p.(type) {
+	case *DefaultProxy:
+		return p.Host
+	case *StripBasePathProxy:
+		return p.Host
+	case *DetectBasePathProxy:
+		return p.Host
+	}
+	return ""
+}
+
+// hostResolver returns the HostResolver of the proxy.
+func hostResolver(p Proxy) HostResolver {
+	switch p := p.(type) {
+	case *DefaultProxy:
+		return p.HostResolver
+	case *StripBasePathProxy:
+		return p.HostResolver
+	case *DetectBasePathProxy:
+		return p.HostResolver
+	}
+	return nil
+}
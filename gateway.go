@@ -2,10 +2,15 @@ package apigo
 
 import (
 	"context"
+	"encoding/json"
+	"log"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/pkg/errors"
 )
 
 // Gateway mimics the http.Server definition and takes care of proxying
@@ -13,11 +18,52 @@ import (
 type Gateway struct {
 	Proxy   Proxy
 	Handler http.Handler
+	// Middlewares wrap the Serve function, i.e. to instrument invocations.
+	// The first middleware is the outermost one.
+	Middlewares []Middleware
+	// WarmUp recognizes warm-up events, which are replied immediately
+	// without invoking the Handler. Warm-up events are not recognized
+	// whether it is nil.
+	WarmUp WarmUpMatcher
+	// OnWarmUp is called whenever a warm-up event has been received, i.e.
+	// to open database connection pools.
+	OnWarmUp func(context.Context) error
+	// OnColdStart is called once, before the first event is handled, i.e.
+	// to run initialization logic.
+	OnColdStart func(context.Context) error
+	// ErrorLog specifies an optional logger for errors which cannot be
+	// returned to the AWS Lambda. The standard logger is used whether it
+	// is nil.
+	ErrorLog *log.Logger
+	// TaskTimeout is a maximum time to wait for background tasks started by
+	// Go before the response is returned. Tasks are awaited until the
+	// invocation's deadline whether it is zero, or up to the
+	// DefaultTaskTimeout whether the invocation has no deadline (i.e. in
+	// tests).
+	TaskTimeout time.Duration
+	// ShutdownTimeout is a maximum time given to the functions registered
+	// by RegisterOnShutdown when SIGTERM has been received.
+	// DefaultShutdownTimeout is used whether it is zero.
+	ShutdownTimeout time.Duration
+
+	mu         sync.Mutex
+	onShutdown []func()
+	startMu    sync.Mutex
+	started    bool
+	invoked    int32
 }
 
+// ServeFunc handles an event from the AWS API Gateway and replies with
+// a response, as Gateway.Serve does.
+type ServeFunc func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+// Middleware wraps a ServeFunc to run some logic around each invocation.
+type Middleware func(ServeFunc) ServeFunc
+
 // NewGateway creates new Gateway, which utilizes handler
 // (or http.DefaultServeMux if nil passed) as a Gateway.Handler and
-// apigo.http.DefaultProxy as a Gateway.Proxy.
+// apigo.http.DefaultProxy as a Gateway.Proxy. Warm-up events are not
+// recognized, unless Gateway.WarmUp is set (i.e. to DefaultWarmUp).
 func NewGateway(host string, handler http.Handler) *Gateway {
 	if handler == nil {
 		handler = http.DefaultServeMux
@@ -25,7 +71,7 @@ func NewGateway(host string, handler http.Handler) *Gateway {
 
 	return &Gateway{
 		Handler: handler,
-		Proxy:   &DefaultProxy{host},
+		Proxy:   &DefaultProxy{Host: host},
 	}
 }
 
@@ -35,15 +81,66 @@ func ListenAndServe(host string, h http.Handler) {
 	NewGateway(host, h).ListenAndServe()
 }
 
-// ListenAndServe registers a listener of AWS Lambda events.
+// ListenAndServe registers a listener of AWS Lambda events. Functions
+// registered by RegisterOnShutdown are called when the AWS Lambda execution
+// environment is shut down.
 func (g *Gateway) ListenAndServe() {
-	lambda.Start(g.Serve)
+	g.listenShutdown()
+	lambda.Start(g)
+}
+
+// Invoke implements the lambda.Handler interface. It replies to warm-up
+// events recognized by WarmUp, otherwise payload is decoded as an
+// APIGatewayProxyRequest and handled by Serve.
+func (g *Gateway) Invoke(ctx context.Context, payload []byte) ([]byte, error) {
+	ctx = g.withColdStart(ctx)
+	if err := g.coldStart(ctx); err != nil {
+		return nil, err
+	}
+
+	if g.WarmUp != nil && g.WarmUp.MatchWarmUp(payload) {
+		if g.OnWarmUp != nil {
+			if err := g.OnWarmUp(ctx); err != nil {
+				return nil, errors.Wrap(err, "warming up")
+			}
+		}
+		return []byte("{}"), nil
+	}
+
+	var e events.APIGatewayProxyRequest
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return nil, errors.Wrap(err, "decoding event")
+	}
+
+	resp, err := g.Serve(ctx, e)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(resp)
 }
 
 // Serve handles incoming event from AWS Lambda by wraping them into
 // http.Request which is further processed by http.Handler to reply
 // as a APIGatewayProxyResponse.
 func (g *Gateway) Serve(ctx context.Context, e events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	ctx = g.withColdStart(ctx)
+	if err := g.coldStart(ctx); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	serve := ServeFunc(g.serve)
+	for i := len(g.Middlewares) - 1; i >= 0; i-- {
+		serve = g.Middlewares[i](serve)
+	}
+
+	return serve(ctx, e)
+}
+
+// serve transforms the event to the http.Request and handles it, then waits
+// for background tasks started by the handler.
+func (g *Gateway) serve(ctx context.Context, e events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	ctx, tasks := g.withTaskGroup(ctx)
+
 	r, err := g.Proxy.Transform(ctx, e)
 	if err != nil {
 		return events.APIGatewayProxyResponse{}, err
@@ -51,6 +148,7 @@ func (g *Gateway) Serve(ctx context.Context, e events.APIGatewayProxyRequest) (e
 
 	w := NewResponse()
 	g.Handler.ServeHTTP(w, r)
+	tasks.wait(ctx, g.taskTimeout(ctx))
 
 	return w.End(), nil
 }
@@ -1,20 +1,42 @@
 package apigo_test
 
 import (
+	"os"
+
 	"context"
 	"encoding/json"
-	"io/ioutil"
 	"net/http"
 	"testing"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/piotrkubisa/apigo"
+	"github.com/stretchr/testify/assert"
 )
 
+func TestGateway_Middlewares(t *testing.T) {
+	var calls []string
+	mw := func(name string) apigo.Middleware {
+		return func(next apigo.ServeFunc) apigo.ServeFunc {
+			return func(ctx context.Context, ev events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+				calls = append(calls, name)
+				return next(ctx, ev)
+			}
+		}
+	}
+
+	g := apigo.NewGateway("api.example.com", http.HandlerFunc(helloHandler))
+	g.Middlewares = []apigo.Middleware{mw("outer"), mw("inner")}
+
+	resp, err := g.Serve(context.TODO(), events.APIGatewayProxyRequest{Path: "/"})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+	assert.Equal(t, []string{"outer", "inner"}, calls)
+}
+
 func BenchmarkGateway_Serve(b *testing.B) {
 	g := apigo.NewGateway("api.example.com", http.HandlerFunc(helloHandler))
 
-	payload, err := ioutil.ReadFile("./vendor/github.com/aws/aws-lambda-go/events/testdata/apigw-request.json")
+	payload, err := os.ReadFile("./vendor/github.com/aws/aws-lambda-go/events/testdata/apigw-request.json")
 	if err != nil {
 		panic(err)
 	}
@@ -33,3 +55,57 @@ func helloHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusTeapot)
 	w.Write([]byte(`"Hello World"`))
 }
+
+func TestGateway_Invoke(t *testing.T) {
+	g := apigo.NewGateway("api.example.com", http.HandlerFunc(helloHandler))
+
+	payload, err := json.Marshal(events.APIGatewayProxyRequest{HTTPMethod: "GET", Path: "/hello"})
+	assert.NoError(t, err)
+
+	out, err := g.Invoke(context.TODO(), payload)
+	assert.NoError(t, err)
+
+	var resp events.APIGatewayProxyResponse
+	assert.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+	assert.Equal(t, `"Hello World"`, resp.Body)
+}
+
+func TestGateway_Invoke_warmUp(t *testing.T) {
+	var served, warmed int
+	g := apigo.NewGateway("api.example.com", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+	}))
+	g.OnWarmUp = func(ctx context.Context) error {
+		warmed++
+		return nil
+	}
+
+	_, err := g.Invoke(context.TODO(), []byte(`{"source": "serverless-plugin-warmup"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, served, "warm-up is opt-in")
+	assert.Equal(t, 0, warmed)
+
+	g.WarmUp = apigo.DefaultWarmUp
+	payloads := []string{
+		`{"source": "serverless-plugin-warmup"}`,
+		`{"source": "apigo.warmup"}`,
+	}
+	for _, p := range payloads {
+		out, err := g.Invoke(context.TODO(), []byte(p))
+		assert.NoError(t, err)
+		assert.Equal(t, `{}`, string(out))
+	}
+	assert.Equal(t, 1, served)
+	assert.Equal(t, 2, warmed)
+
+	_, err = g.Invoke(context.TODO(), []byte(`{"version": "0", "source": "aws.events", "detail-type": "Scheduled Event", "detail": {}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, served)
+
+	g.WarmUp = apigo.WarmUpSource("custom.warmer")
+	_, err = g.Invoke(context.TODO(), []byte(`{"source": "custom.warmer"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, served)
+	assert.Equal(t, 3, warmed)
+}
@@ -0,0 +1,31 @@
+package apigo
+
+import (
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// EventHeaders returns headers from the event as a http.Header. Headers are
+// taken from the MultiValueHeaders and merged with the Headers, which have
+// not been defined in the former. Header names are canonicalized, so they
+// can be accessed case-insensitively.
+func EventHeaders(ev events.APIGatewayProxyRequest) http.Header {
+	h := make(http.Header, len(ev.MultiValueHeaders))
+	for k, vs := range ev.MultiValueHeaders {
+		for _, v := range vs {
+			h.Add(k, v)
+		}
+	}
+	for k, v := range ev.Headers {
+		if _, ok := h[http.CanonicalHeaderKey(k)]; !ok {
+			h.Set(k, v)
+		}
+	}
+	return h
+}
+
+// eventHeader returns a first value of the header from the event.
+func eventHeader(ev events.APIGatewayProxyRequest, name string) string {
+	return EventHeaders(ev).Get(name)
+}
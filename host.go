@@ -0,0 +1,85 @@
+package apigo
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// DefaultScheme is a scheme used in the URL of the http.Request whether
+// X-Forwarded-Proto header has not been provided in the event.
+const DefaultScheme = "https"
+
+// HostResolver determines the host of the http.Request created from an event
+// provided from the API Gateway.
+type HostResolver interface {
+	ResolveHost(events.APIGatewayProxyRequest) string
+}
+
+// HostResolverFunc implements the HostResolver interface to allow use of
+// ordinary function as a resolver.
+type HostResolverFunc func(events.APIGatewayProxyRequest) string
+
+// ResolveHost calls f(ev).
+func (f HostResolverFunc) ResolveHost(ev events.APIGatewayProxyRequest) string {
+	return f(ev)
+}
+
+// StaticHost returns a HostResolver which always resolves to the given host.
+func StaticHost(host string) HostResolver {
+	return HostResolverFunc(func(events.APIGatewayProxyRequest) string {
+		return host
+	})
+}
+
+// HeaderHost resolves a host from the Host header of the event.
+var HeaderHost HostResolver = HostResolverFunc(func(ev events.APIGatewayProxyRequest) string {
+	return eventHeader(ev, "Host")
+})
+
+// DomainNameHost resolves a host from the DomainName of the event's
+// Request Context.
+var DomainNameHost HostResolver = HostResolverFunc(func(ev events.APIGatewayProxyRequest) string {
+	return ev.RequestContext.DomainName
+})
+
+// EventHost resolves a host from the Host header and falls back to the
+// DomainName of the event's Request Context.
+var EventHost = FirstHost(HeaderHost, DomainNameHost)
+
+// FirstHost returns a HostResolver which resolves to the first non-empty
+// host returned by the given resolvers.
+func FirstHost(resolvers ...HostResolver) HostResolver {
+	return HostResolverFunc(func(ev events.APIGatewayProxyRequest) string {
+		for _, hr := range resolvers {
+			if host := hr.ResolveHost(ev); host != "" {
+				return host
+			}
+		}
+		return ""
+	})
+}
+
+// HostMap maps a domain name the event has been sent to (i.e. the
+// auto-generated "execute-api" address) to a custom domain name, which
+// should be used as a host of the http.Request.
+type HostMap map[string]string
+
+// ResolveHost returns a custom domain mapped to the DomainName of the event's
+// Request Context or its Host header.
+func (m HostMap) ResolveHost(ev events.APIGatewayProxyRequest) string {
+	if host, ok := m[ev.RequestContext.DomainName]; ok {
+		return host
+	}
+	return m[eventHeader(ev, "Host")]
+}
+
+// resolveHost returns a host resolved by hr or the static host whether
+// resolver has not been defined or it could not determine the host.
+func resolveHost(hr HostResolver, host string, ev events.APIGatewayProxyRequest) string {
+	if hr == nil {
+		return host
+	}
+	if h := hr.ResolveHost(ev); h != "" {
+		return h
+	}
+	return host
+}
@@ -0,0 +1,85 @@
+package apigo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostResolver(t *testing.T) {
+	e := events.APIGatewayProxyRequest{
+		Headers: map[string]string{
+			"host": "api.example.com",
+		},
+		RequestContext: events.APIGatewayProxyRequestContext{
+			DomainName: "xxxxxxxxxx.execute-api.us-east-1.amazonaws.com",
+		},
+	}
+
+	assert.Equal(t, "static.example.com", StaticHost("static.example.com").ResolveHost(e))
+	assert.Equal(t, "api.example.com", HeaderHost.ResolveHost(e))
+	assert.Equal(t, "xxxxxxxxxx.execute-api.us-east-1.amazonaws.com", DomainNameHost.ResolveHost(e))
+	assert.Equal(t, "api.example.com", EventHost.ResolveHost(e))
+
+	e.Headers = nil
+	assert.Equal(t, "xxxxxxxxxx.execute-api.us-east-1.amazonaws.com", EventHost.ResolveHost(e))
+
+	m := HostMap{"xxxxxxxxxx.execute-api.us-east-1.amazonaws.com": "pets.example.com"}
+	assert.Equal(t, "pets.example.com", m.ResolveHost(e))
+}
+
+func TestDefaultProxy_hostResolver(t *testing.T) {
+	e := events.APIGatewayProxyRequest{
+		Path: "/pets",
+		MultiValueHeaders: map[string][]string{
+			"Host": {"api.example.com"},
+		},
+	}
+
+	t.Run("Resolved", func(t *testing.T) {
+		p := &DefaultProxy{Host: "fallback.example.com", HostResolver: EventHost}
+		r, err := p.Transform(context.TODO(), e)
+		assert.NoError(t, err)
+		assert.Equal(t, "api.example.com", r.Host)
+		assert.Equal(t, "https://api.example.com/pets", r.URL.String())
+	})
+
+	t.Run("Fallback", func(t *testing.T) {
+		p := &DefaultProxy{Host: "fallback.example.com", HostResolver: DomainNameHost}
+		r, err := p.Transform(context.TODO(), e)
+		assert.NoError(t, err)
+		assert.Equal(t, "fallback.example.com", r.Host)
+	})
+
+	t.Run("HostMap", func(t *testing.T) {
+		e := e
+		e.MultiValueHeaders = map[string][]string{"Host": {"xxxxxxxxxx.execute-api.us-east-1.amazonaws.com"}}
+		m := HostMap{"xxxxxxxxxx.execute-api.us-east-1.amazonaws.com": "pets.example.com"}
+		r, err := (&DefaultProxy{HostResolver: m}).Transform(context.TODO(), e)
+		assert.NoError(t, err)
+		assert.Equal(t, "pets.example.com", r.Host)
+		assert.Equal(t, "pets.example.com", r.URL.Host)
+		assert.Empty(t, r.Header.Get("Host"))
+	})
+
+	t.Run("StaticHostWithHeader", func(t *testing.T) {
+		p := &DefaultProxy{Host: "api.example.com"}
+		e := e
+		e.MultiValueHeaders = map[string][]string{"Host": {"xxxxxxxxxx.execute-api.us-east-1.amazonaws.com"}}
+		r, err := p.Transform(context.TODO(), e)
+		assert.NoError(t, err)
+		assert.Equal(t, "api.example.com", r.Host)
+		assert.Equal(t, "api.example.com", r.URL.Host)
+	})
+
+	t.Run("ForwardedProto", func(t *testing.T) {
+		e := e
+		e.Headers = map[string]string{"X-Forwarded-Proto": "http"}
+		p := &DefaultProxy{Host: "api.example.com"}
+		r, err := p.Transform(context.TODO(), e)
+		assert.NoError(t, err)
+		assert.Equal(t, "http://api.example.com/pets", r.URL.String())
+	})
+}
@@ -0,0 +1,182 @@
+package apigo
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultShutdownTimeout is a time given to the functions registered by
+// Gateway.RegisterOnShutdown when SIGTERM has been received, whether
+// Gateway.ShutdownTimeout is zero. AWS Lambda gives 500ms to the runtime
+// with internal extensions before it is killed.
+const DefaultShutdownTimeout = 450 * time.Millisecond
+
+// extensionName is a name of the internal extension registered by the
+// Gateway to enable SIGTERM.
+const extensionName = "apigo"
+
+// RegisterOnShutdown registers a function to call on Shutdown, i.e. to flush
+// buffers before the AWS Lambda execution environment is shut down.
+// Functions should be registered before ListenAndServe is called.
+func (g *Gateway) RegisterOnShutdown(f func()) {
+	g.mu.Lock()
+	g.onShutdown = append(g.onShutdown, f)
+	g.mu.Unlock()
+}
+
+// Shutdown calls functions registered by RegisterOnShutdown and waits for
+// them to return or ctx to be done.
+func (g *Gateway) Shutdown(ctx context.Context) error {
+	g.mu.Lock()
+	fns := g.onShutdown
+	g.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		for _, f := range fns {
+			f()
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// coldStart calls OnColdStart once, it is called again on the next
+// invocation whether it has failed.
+func (g *Gateway) coldStart(ctx context.Context) error {
+	g.startMu.Lock()
+	defer g.startMu.Unlock()
+
+	if g.started || g.OnColdStart == nil {
+		return nil
+	}
+	if err := g.OnColdStart(ctx); err != nil {
+		return errors.Wrap(err, "cold start")
+	}
+	g.started = true
+	return nil
+}
+
+// withColdStart returns a context, which tells whether the invocation is
+// the first one handled by the Gateway. Context is returned as is whether it
+// has been already marked (i.e. by Invoke before Serve).
+func (g *Gateway) withColdStart(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(coldStartKey).(bool); ok {
+		return ctx
+	}
+	cold := atomic.CompareAndSwapInt32(&g.invoked, 0, 1)
+	return context.WithValue(ctx, coldStartKey, cold)
+}
+
+// listenShutdown calls Shutdown whenever SIGTERM has been received and then
+// terminates the process. AWS Lambda sends SIGTERM only when an extension
+// has been registered, so the internal extension is registered when running
+// within AWS Lambda.
+func (g *Gateway) listenShutdown() {
+	g.mu.Lock()
+	n := len(g.onShutdown)
+	g.mu.Unlock()
+	if n == 0 {
+		return
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGTERM)
+	go g.shutdown(sig)
+
+	if api := os.Getenv("AWS_LAMBDA_RUNTIME_API"); api != "" {
+		if err := registerExtension(api, extensionName); err != nil {
+			g.logf("apigo: SIGTERM may not be delivered: %v", err)
+		}
+	}
+}
+
+// shutdown calls Shutdown once the signal has been received and then
+// re-raises it, so the process terminates as it would without the handler.
+func (g *Gateway) shutdown(sig chan os.Signal) {
+	s := <-sig
+
+	timeout := g.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := g.Shutdown(ctx); err != nil {
+		g.logf("apigo: shutdown: %v", err)
+	}
+
+	signal.Stop(sig)
+	signal.Reset(s)
+	p, err := os.FindProcess(os.Getpid())
+	if err == nil {
+		err = p.Signal(s)
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// registerExtension registers an internal extension, which does not listen
+// for any event, in the Extensions API available at api.
+func registerExtension(api, name string) error {
+	base := "http://" + api + "/2020-01-01/extension/"
+
+	req, err := http.NewRequest(http.MethodPost, base+"register", bytes.NewReader([]byte(`{"events":[]}`)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Lambda-Extension-Name", name)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "registering extension")
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		return errors.Errorf("registering extension: unexpected status %d", res.StatusCode)
+	}
+	id := res.Header.Get("Lambda-Extension-Identifier")
+
+	// Extension has to ask for the next event to complete initialization,
+	// it never gets any event, so the call blocks forever.
+	go func() {
+		req, err := http.NewRequest(http.MethodGet, base+"event/next", nil)
+		if err != nil {
+			return
+		}
+		req.Header.Set("Lambda-Extension-Identifier", id)
+		if res, err := http.DefaultClient.Do(req); err == nil {
+			res.Body.Close()
+		}
+	}()
+
+	return nil
+}
+
+// logf logs using ErrorLog of the Gateway or the standard logger.
+func (g *Gateway) logf(format string, args ...interface{}) {
+	if g.ErrorLog != nil {
+		g.ErrorLog.Printf(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
@@ -0,0 +1,105 @@
+package apigo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGateway_OnColdStart(t *testing.T) {
+	var calls int
+	g := NewGateway("api.example.com", http.NotFoundHandler())
+	g.OnColdStart = func(ctx context.Context) error {
+		calls++
+		if calls == 1 {
+			return errors.New("database unavailable")
+		}
+		return nil
+	}
+
+	_, err := g.Serve(context.TODO(), events.APIGatewayProxyRequest{})
+	assert.EqualError(t, err, "cold start: database unavailable")
+
+	for i := 0; i < 2; i++ {
+		resp, err := g.Serve(context.TODO(), events.APIGatewayProxyRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	}
+	assert.Equal(t, 2, calls)
+}
+
+func TestGateway_Shutdown(t *testing.T) {
+	var calls []string
+	g := NewGateway("api.example.com", nil)
+	g.RegisterOnShutdown(func() { calls = append(calls, "flush") })
+	g.RegisterOnShutdown(func() { calls = append(calls, "close") })
+
+	assert.NoError(t, g.Shutdown(context.TODO()))
+	assert.Equal(t, []string{"flush", "close"}, calls)
+
+	g.RegisterOnShutdown(func() { time.Sleep(time.Second) })
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, g.Shutdown(ctx))
+}
+
+func TestRegisterExtension(t *testing.T) {
+	next := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/2020-01-01/extension/register":
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "apigo", r.Header.Get("Lambda-Extension-Name"))
+			w.Header().Set("Lambda-Extension-Identifier", "ext-id")
+		case "/2020-01-01/extension/event/next":
+			next <- r.Header.Get("Lambda-Extension-Identifier")
+			<-r.Context().Done()
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	defer srv.CloseClientConnections()
+
+	api := srv.Listener.Addr().String()
+	assert.NoError(t, registerExtension(api, extensionName))
+	assert.Equal(t, "ext-id", <-next)
+
+	srv.Config.Handler = http.NotFoundHandler()
+	assert.Error(t, registerExtension(api, extensionName))
+}
+
+func TestGateway_listenShutdown(t *testing.T) {
+	if os.Getenv("APIGO_TEST_SIGTERM") == "1" {
+		g := NewGateway("api.example.com", nil)
+		g.RegisterOnShutdown(func() { fmt.Println("flushed") })
+		g.listenShutdown()
+
+		p, _ := os.FindProcess(os.Getpid())
+		p.Signal(syscall.SIGTERM)
+		time.Sleep(5 * time.Second)
+		os.Exit(3)
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestGateway_listenShutdown$")
+	cmd.Env = append(os.Environ(), "APIGO_TEST_SIGTERM=1")
+	out, err := cmd.Output()
+	assert.Equal(t, "flushed\n", string(out))
+
+	var exitErr *exec.ExitError
+	if assert.True(t, errors.As(err, &exitErr), "process should be terminated by SIGTERM") {
+		ws := exitErr.Sys().(syscall.WaitStatus)
+		assert.True(t, ws.Signaled())
+		assert.Equal(t, syscall.SIGTERM, ws.Signal())
+	}
+}
@@ -0,0 +1,238 @@
+package apigo
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// DefaultMetricsNamespace is a CloudWatch namespace used whether none has
+// been provided to the Metrics.
+const DefaultMetricsNamespace = "apigo"
+
+// Metrics is a Gateway middleware which emits request metrics in the
+// CloudWatch Embedded Metric Format (EMF). Each invocation records Latency,
+// ResponseBytes, ColdStart and 2XX, 4XX, 5XX counts with the Route dimension
+// (i.e. "GET /pets/{id}").
+//
+// Records are written as soon as BatchSize of them have been collected for
+// the same route (or a record of the next minute arrives), so Flush has to
+// be called before the execution environment is shut down whether BatchSize
+// is greater than one. Register does it by the Gateway.RegisterOnShutdown.
+type Metrics struct {
+	// Namespace is a CloudWatch namespace of the metrics.
+	Namespace string
+	// Writer is where EMF documents are written to, os.Stdout is used
+	// whether it is nil.
+	Writer io.Writer
+	// BatchSize is a maximum number of invocations (up to 100) described
+	// by a single EMF document.
+	BatchSize int
+	// ErrorLog specifies an optional logger for errors of writing EMF
+	// documents, which never fail the invocation. The standard logger is
+	// used whether it is nil.
+	ErrorLog *log.Logger
+
+	mu      sync.Mutex
+	started bool
+	batches map[string][]metricRecord
+}
+
+// metricRecord are metrics of a single invocation.
+type metricRecord struct {
+	timestamp     time.Time
+	latency       time.Duration
+	status        int
+	responseBytes int
+	coldStart     bool
+}
+
+// metricDefinition describes a metric in the EMF document.
+type metricDefinition struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+// metricDefinitions are metrics emitted by the Metrics.
+var metricDefinitions = []metricDefinition{
+	{"Latency", "Milliseconds"},
+	{"ResponseBytes", "Bytes"},
+	{"ColdStart", "Count"},
+	{"2XX", "Count"},
+	{"4XX", "Count"},
+	{"5XX", "Count"},
+}
+
+// Middleware records metrics of each invocation handled by the Gateway.
+func (m *Metrics) Middleware(next ServeFunc) ServeFunc {
+	return func(ctx context.Context, ev events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		start := time.Now()
+		resp, err := next(ctx, ev)
+
+		cold, ok := ctx.Value(coldStartKey).(bool)
+		rec := metricRecord{
+			timestamp:     start,
+			coldStart:     cold,
+			latency:       time.Since(start),
+			status:        resp.StatusCode,
+			responseBytes: responseLength(resp),
+		}
+		if err != nil {
+			rec.status = http.StatusBadGateway
+		}
+
+		route := ev.HTTPMethod + " " + ev.Resource
+		if werr := m.record(route, rec, ok); werr != nil {
+			m.logf("metrics: writing EMF document: %v", werr)
+		}
+		return resp, err
+	}
+}
+
+// Register adds the Middleware to the Gateway and registers Flush to be
+// called when the execution environment is shut down, so the last partial
+// batch is not lost.
+func (m *Metrics) Register(g *Gateway) {
+	g.Middlewares = append(g.Middlewares, m.Middleware)
+	g.RegisterOnShutdown(func() {
+		if err := m.Flush(); err != nil {
+			m.logf("metrics: writing EMF document: %v", err)
+		}
+	})
+}
+
+// record adds the record to the batch of the route and writes the batch
+// whether it is full. Cold start is determined by the Metrics itself whether
+// it is not known from the Gateway. A batch covers a single minute, because
+// the EMF document has a single timestamp, so the pending batch is written
+// first whether the record belongs to another minute.
+func (m *Metrics) record(route string, rec metricRecord, known bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !known {
+		rec.coldStart = !m.started
+	}
+	m.started = true
+
+	if m.batches == nil {
+		m.batches = make(map[string][]metricRecord)
+	}
+	var err error
+	if recs := m.batches[route]; len(recs) > 0 && !sameMinute(recs[0].timestamp, rec.timestamp) {
+		err = m.flush(route)
+	}
+	m.batches[route] = append(m.batches[route], rec)
+
+	size := m.BatchSize
+	if size > 100 {
+		size = 100
+	}
+	if len(m.batches[route]) < size {
+		return err
+	}
+	if ferr := m.flush(route); ferr != nil {
+		return ferr
+	}
+	return err
+}
+
+// sameMinute reports whether t1 and t2 belong to the same minute.
+func sameMinute(t1, t2 time.Time) bool {
+	return t1.Truncate(time.Minute).Equal(t2.Truncate(time.Minute))
+}
+
+// Flush writes all pending records.
+func (m *Metrics) Flush() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for route := range m.batches {
+		if err := m.flush(route); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flush writes pending records of the route as a single EMF document.
+func (m *Metrics) flush(route string) error {
+	recs := m.batches[route]
+	delete(m.batches, route)
+	if len(recs) == 0 {
+		return nil
+	}
+
+	ns := m.Namespace
+	if ns == "" {
+		ns = DefaultMetricsNamespace
+	}
+
+	doc := map[string]interface{}{
+		"_aws": map[string]interface{}{
+			"Timestamp": recs[0].timestamp.UnixNano() / int64(time.Millisecond),
+			"CloudWatchMetrics": []interface{}{
+				map[string]interface{}{
+					"Namespace":  ns,
+					"Dimensions": [][]string{{"Route"}},
+					"Metrics":    metricDefinitions,
+				},
+			},
+		},
+		"Route": route,
+	}
+
+	values := make(map[string][]float64, len(metricDefinitions))
+	for _, rec := range recs {
+		class := rec.status / 100
+		values["Latency"] = append(values["Latency"], float64(rec.latency)/float64(time.Millisecond))
+		values["ResponseBytes"] = append(values["ResponseBytes"], float64(rec.responseBytes))
+		values["ColdStart"] = append(values["ColdStart"], boolMetric(rec.coldStart))
+		values["2XX"] = append(values["2XX"], boolMetric(class == 2))
+		values["4XX"] = append(values["4XX"], boolMetric(class == 4))
+		values["5XX"] = append(values["5XX"], boolMetric(class == 5))
+	}
+	for name, vs := range values {
+		if len(vs) == 1 {
+			doc[name] = vs[0]
+		} else {
+			doc[name] = vs
+		}
+	}
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	w := m.Writer
+	if w == nil {
+		w = os.Stdout
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// logf logs using ErrorLog of the Metrics or the standard logger.
+func (m *Metrics) logf(format string, args ...interface{}) {
+	if m.ErrorLog != nil {
+		m.ErrorLog.Printf(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
+
+// boolMetric returns 1 whether b is true, otherwise 0.
+func boolMetric(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,181 @@
+package apigo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeEMF(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var docs []map[string]interface{}
+	s := bufio.NewScanner(buf)
+	for s.Scan() {
+		var doc map[string]interface{}
+		if err := json.Unmarshal(s.Bytes(), &doc); err != nil {
+			t.Fatal(err)
+		}
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+func TestMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	m := &Metrics{Namespace: "pets", Writer: &buf}
+
+	g := NewGateway("api.example.com", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("Not Found\n"))
+	}))
+	g.Middlewares = append(g.Middlewares, m.Middleware)
+
+	ev := events.APIGatewayProxyRequest{HTTPMethod: "GET", Resource: "/pets/{id}", Path: "/pets/luna"}
+	for i := 0; i < 2; i++ {
+		_, err := g.Serve(context.TODO(), ev)
+		assert.NoError(t, err)
+	}
+
+	docs := decodeEMF(t, &buf)
+	if !assert.Len(t, docs, 2) {
+		return
+	}
+
+	doc := docs[0]
+	aws := doc["_aws"].(map[string]interface{})
+	cwm := aws["CloudWatchMetrics"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "pets", cwm["Namespace"])
+	assert.Equal(t, []interface{}{[]interface{}{"Route"}}, cwm["Dimensions"])
+	assert.Len(t, cwm["Metrics"], 6)
+
+	assert.Equal(t, "GET /pets/{id}", doc["Route"])
+	assert.Equal(t, float64(10), doc["ResponseBytes"])
+	assert.Equal(t, float64(1), doc["4XX"])
+	assert.Equal(t, float64(0), doc["5XX"])
+	assert.Equal(t, float64(1), doc["ColdStart"])
+	assert.Contains(t, doc, "Latency")
+
+	assert.Equal(t, float64(0), docs[1]["ColdStart"])
+}
+
+func TestMetrics_batch(t *testing.T) {
+	var buf bytes.Buffer
+	m := &Metrics{Writer: &buf, BatchSize: 3}
+
+	serve := m.Middleware(func(ctx context.Context, ev events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	})
+
+	for _, resource := range []string{"/pets", "/pets", "/toys", "/pets"} {
+		_, err := serve(context.TODO(), events.APIGatewayProxyRequest{HTTPMethod: "GET", Resource: resource})
+		assert.NoError(t, err)
+	}
+
+	docs := decodeEMF(t, &buf)
+	if assert.Len(t, docs, 1) {
+		assert.Equal(t, "GET /pets", docs[0]["Route"])
+		assert.Equal(t, []interface{}{float64(1), float64(1), float64(1)}, docs[0]["2XX"])
+		assert.Equal(t, []interface{}{float64(1), float64(0), float64(0)}, docs[0]["ColdStart"])
+	}
+
+	assert.NoError(t, m.Flush())
+	docs = decodeEMF(t, &buf)
+	if assert.Len(t, docs, 1) {
+		assert.Equal(t, "GET /toys", docs[0]["Route"])
+		assert.Equal(t, DefaultMetricsNamespace, docs[0]["_aws"].(map[string]interface{})["CloudWatchMetrics"].([]interface{})[0].(map[string]interface{})["Namespace"])
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestMetrics_writeError(t *testing.T) {
+	var logs bytes.Buffer
+	m := &Metrics{Writer: failingWriter{}, ErrorLog: log.New(&logs, "", 0)}
+
+	serve := m.Middleware(func(ctx context.Context, ev events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	})
+
+	resp, err := serve(context.TODO(), events.APIGatewayProxyRequest{HTTPMethod: "GET", Resource: "/pets"})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "metrics: writing EMF document: broken pipe\n", logs.String())
+}
+
+func TestMetrics_Register(t *testing.T) {
+	var buf bytes.Buffer
+	m := &Metrics{Writer: &buf, BatchSize: 10}
+
+	g := NewGateway("api.example.com", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	m.Register(g)
+
+	_, err := g.Serve(context.TODO(), events.APIGatewayProxyRequest{HTTPMethod: "GET", Resource: "/pets"})
+	assert.NoError(t, err)
+	assert.Empty(t, buf.String())
+
+	assert.NoError(t, g.Shutdown(context.TODO()))
+	docs := decodeEMF(t, &buf)
+	if assert.Len(t, docs, 1) {
+		assert.Equal(t, "GET /pets", docs[0]["Route"])
+		assert.Equal(t, float64(1), docs[0]["2XX"])
+	}
+}
+
+func TestMetrics_timestamp(t *testing.T) {
+	var buf bytes.Buffer
+	m := &Metrics{Writer: &buf, BatchSize: 10}
+
+	first := time.Date(2020, 1, 1, 12, 0, 59, 0, time.UTC)
+	for _, ts := range []time.Time{first, first.Add(time.Second), first.Add(2 * time.Second)} {
+		assert.NoError(t, m.record("GET /pets", metricRecord{timestamp: ts, status: http.StatusOK}, true))
+	}
+
+	docs := decodeEMF(t, &buf)
+	if assert.Len(t, docs, 1) {
+		aws := docs[0]["_aws"].(map[string]interface{})
+		assert.Equal(t, float64(first.UnixNano()/int64(time.Millisecond)), aws["Timestamp"])
+		assert.Equal(t, float64(1), docs[0]["2XX"])
+	}
+
+	assert.NoError(t, m.Flush())
+	docs = decodeEMF(t, &buf)
+	if assert.Len(t, docs, 1) {
+		aws := docs[0]["_aws"].(map[string]interface{})
+		assert.Equal(t, float64(first.Add(time.Second).UnixNano()/int64(time.Millisecond)), aws["Timestamp"])
+		assert.Equal(t, []interface{}{float64(1), float64(1)}, docs[0]["2XX"])
+	}
+}
+
+func TestMetrics_coldStartOfGateway(t *testing.T) {
+	var buf bytes.Buffer
+	m := &Metrics{Writer: &buf}
+
+	g := NewGateway("api.example.com", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	_, err := g.Serve(context.TODO(), events.APIGatewayProxyRequest{HTTPMethod: "GET", Resource: "/pets"})
+	assert.NoError(t, err)
+
+	// Metrics added after the first invocation still knows it is not a
+	// cold start, because it is tracked by the Gateway.
+	g.Middlewares = append(g.Middlewares, m.Middleware)
+	_, err = g.Serve(context.TODO(), events.APIGatewayProxyRequest{HTTPMethod: "GET", Resource: "/pets"})
+	assert.NoError(t, err)
+
+	docs := decodeEMF(t, &buf)
+	if assert.Len(t, docs, 1) {
+		assert.Equal(t, float64(0), docs[0]["ColdStart"])
+	}
+}
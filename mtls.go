@@ -0,0 +1,91 @@
+package apigo
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNoClientCert is returned by ClientCertVerifier whether the request does
+// not contain any client certificate.
+var ErrNoClientCert = errors.New("no client certificate")
+
+// ErrNoRoots is returned by ClientCertVerifier whether no certificate
+// authorities have been configured, so the system ones are not trusted by
+// accident.
+var ErrNoRoots = errors.New("no trusted certificate authorities")
+
+// ClientCertVerifier is a middleware which verifies client certificates
+// provided by the API Gateway with mutual TLS against a configured pool of
+// certificate authorities and maps the subject of the verified certificate
+// to the principal, which is available via Principal function.
+type ClientCertVerifier struct {
+	// Roots is a pool of certificate authorities trusted to issue client
+	// certificates. Every certificate is rejected whether it is nil.
+	Roots *x509.CertPool
+	// Principal maps a verified client certificate to the principal.
+	// Common Name of the certificate's subject is used whether it is nil.
+	Principal func(*x509.Certificate) string
+}
+
+// NewClientCertVerifier creates new ClientCertVerifier which trusts
+// certificate authorities from the PEM encoded bundle.
+func NewClientCertVerifier(caBundle string) (*ClientCertVerifier, error) {
+	cas, err := ParseCertificates(caBundle)
+	if err != nil {
+		return nil, err
+	}
+
+	roots := x509.NewCertPool()
+	for _, ca := range cas {
+		roots.AddCert(ca)
+	}
+	return &ClientCertVerifier{Roots: roots}, nil
+}
+
+// Verify verifies the client certificate of the request and returns the
+// principal mapped from its subject.
+func (v *ClientCertVerifier) Verify(r *http.Request) (string, error) {
+	if v.Roots == nil {
+		return "", ErrNoRoots
+	}
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return "", ErrNoClientCert
+	}
+
+	certs := r.TLS.PeerCertificates
+	opts := x509.VerifyOptions{
+		Roots:         v.Roots,
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	for _, c := range certs[1:] {
+		opts.Intermediates.AddCert(c)
+	}
+	if _, err := certs[0].Verify(opts); err != nil {
+		return "", err
+	}
+
+	if v.Principal != nil {
+		return v.Principal(certs[0]), nil
+	}
+	return certs[0].Subject.CommonName, nil
+}
+
+// Handler returns a http.Handler which replies with 403 Forbidden whether
+// the client certificate could not be verified, otherwise the principal is
+// attached to the request's context and the request is passed to next.
+func (v *ClientCertVerifier) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := v.Verify(r)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), principalKey, principal)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
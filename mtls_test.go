@@ -0,0 +1,97 @@
+package apigo
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientCertVerifier(t *testing.T) {
+	trusted := testCertificate(t, "trusted.example.com")
+	v, err := NewClientCertVerifier(trusted)
+	assert.NoError(t, err)
+
+	h := v.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, _ := Principal(r.Context())
+		w.Write([]byte(p))
+	}))
+
+	serve := func(cert string) *httptest.ResponseRecorder {
+		e := events.APIGatewayProxyRequest{Path: "/"}
+		if cert != "" {
+			e.RequestContext.Identity.ClientCert = &events.APIGatewayCustomAuthorizerRequestTypeRequestIdentityClientCert{
+				ClientCertPem: cert,
+			}
+		}
+		r, err := new(DefaultProxy).Transform(context.TODO(), e)
+		assert.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("Trusted", func(t *testing.T) {
+		w := serve(trusted)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "trusted.example.com", w.Body.String())
+	})
+
+	t.Run("Untrusted", func(t *testing.T) {
+		w := serve(testCertificate(t, "untrusted.example.com"))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		w := serve("")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestClientCertVerifier_Principal(t *testing.T) {
+	cert := testCertificate(t, "client.example.com")
+	v, err := NewClientCertVerifier(cert)
+	assert.NoError(t, err)
+	v.Principal = func(c *x509.Certificate) string {
+		return "user:" + c.Subject.CommonName
+	}
+
+	e := events.APIGatewayProxyRequest{Path: "/"}
+	e.RequestContext.Identity.ClientCert = &events.APIGatewayCustomAuthorizerRequestTypeRequestIdentityClientCert{
+		ClientCertPem: cert,
+	}
+	r, err := new(DefaultProxy).Transform(context.TODO(), e)
+	assert.NoError(t, err)
+
+	p, err := v.Verify(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "user:client.example.com", p)
+
+	r.TLS = nil
+	_, err = v.Verify(r)
+	assert.Equal(t, ErrNoClientCert, err)
+}
+
+func TestClientCertVerifier_zeroValue(t *testing.T) {
+	e := events.APIGatewayProxyRequest{Path: "/"}
+	e.RequestContext.Identity.ClientCert = &events.APIGatewayCustomAuthorizerRequestTypeRequestIdentityClientCert{
+		ClientCertPem: testCertificate(t, "client.example.com"),
+	}
+	r, err := new(DefaultProxy).Transform(context.TODO(), e)
+	assert.NoError(t, err)
+
+	v := new(ClientCertVerifier)
+	_, err = v.Verify(r)
+	assert.Equal(t, ErrNoRoots, err)
+
+	w := httptest.NewRecorder()
+	v.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler should not be called")
+	})).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
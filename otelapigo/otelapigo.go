@@ -0,0 +1,129 @@
+// Package otelapigo provides OpenTelemetry instrumentation for the
+// apigo.Gateway, which creates a server span for each invocation.
+package otelapigo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/piotrkubisa/apigo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope name of the tracer.
+const ScopeName = "github.com/piotrkubisa/apigo/otelapigo"
+
+// Instrumentation creates a server span for each invocation handled by the
+// apigo.Gateway. Spans follow the OpenTelemetry semantic conventions for
+// HTTP servers and FaaS.
+type Instrumentation struct {
+	// TracerProvider is used to create spans. The global TracerProvider is
+	// used whether it is nil.
+	TracerProvider trace.TracerProvider
+	// Propagator extracts a parent span from the event's headers. The global
+	// TextMapPropagator is used whether it is nil.
+	Propagator propagation.TextMapPropagator
+}
+
+// Middleware instruments invocations of the apigo.Gateway, i.e.:
+//
+//	g.Middlewares = append(g.Middlewares, new(otelapigo.Instrumentation).Middleware)
+func (i *Instrumentation) Middleware(next apigo.ServeFunc) apigo.ServeFunc {
+	tp := i.TracerProvider
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	tracer := tp.Tracer(ScopeName)
+
+	return func(ctx context.Context, ev events.APIGatewayProxyRequest) (resp events.APIGatewayProxyResponse, err error) {
+		prop := i.Propagator
+		if prop == nil {
+			prop = otel.GetTextMapPropagator()
+		}
+		ctx = prop.Extract(ctx, propagation.HeaderCarrier(apigo.EventHeaders(ev)))
+
+		name := ev.HTTPMethod
+		if ev.Resource != "" {
+			name += " " + ev.Resource
+		}
+
+		ctx, span := tracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attributes(ctx, ev)...),
+		)
+		defer func() {
+			if p := recover(); p != nil {
+				span.RecordError(fmt.Errorf("%v", p), trace.WithStackTrace(true))
+				span.SetStatus(codes.Error, "panic")
+				span.End()
+				panic(p)
+			}
+			span.End()
+		}()
+
+		resp, err = next(ctx, ev)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return resp, err
+		}
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+		if resp.StatusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+		}
+
+		return resp, err
+	}
+}
+
+// attributes returns span attributes describing the invocation.
+func attributes(ctx context.Context, ev events.APIGatewayProxyRequest) []attribute.KeyValue {
+	rc := ev.RequestContext
+	attrs := []attribute.KeyValue{
+		semconv.FaaSTriggerHTTP,
+		semconv.FaaSColdstart(apigo.ColdStart(ctx)),
+		semconv.HTTPRequestMethodKey.String(ev.HTTPMethod),
+		semconv.URLPath(ev.Path),
+		semconv.AWSRequestID(rc.RequestID),
+	}
+
+	if ev.Resource != "" {
+		attrs = append(attrs, semconv.HTTPRoute(ev.Resource))
+	}
+	if rc.Identity.SourceIP != "" {
+		attrs = append(attrs, semconv.ClientAddress(rc.Identity.SourceIP))
+	}
+	if rc.Identity.UserAgent != "" {
+		attrs = append(attrs, semconv.UserAgentOriginal(rc.Identity.UserAgent))
+	}
+	if rc.DomainName != "" {
+		attrs = append(attrs, semconv.ServerAddress(rc.DomainName))
+	}
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		attrs = append(attrs, semconv.FaaSInvocationID(lc.AwsRequestID))
+		if lc.InvokedFunctionArn != "" {
+			attrs = append(attrs, semconv.CloudResourceID(lc.InvokedFunctionArn))
+		}
+	}
+	if name := lambdacontext.FunctionName; name != "" {
+		attrs = append(attrs, semconv.FaaSName(name))
+	}
+	if version := lambdacontext.FunctionVersion; version != "" {
+		attrs = append(attrs, semconv.FaaSVersion(version))
+	}
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		attrs = append(attrs, semconv.CloudRegion(region))
+	}
+
+	return attrs
+}
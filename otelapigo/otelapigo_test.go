@@ -0,0 +1,133 @@
+package otelapigo
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/piotrkubisa/apigo"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newGateway(h http.HandlerFunc) (*apigo.Gateway, *tracetest.InMemoryExporter) {
+	exp := tracetest.NewInMemoryExporter()
+	i := &Instrumentation{
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp)),
+		Propagator:     propagation.TraceContext{},
+	}
+
+	g := apigo.NewGateway("api.example.com", h)
+	g.Middlewares = append(g.Middlewares, i.Middleware)
+	return g, exp
+}
+
+func attrs(s tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	m := make(map[attribute.Key]attribute.Value)
+	for _, kv := range s.Attributes {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func TestInstrumentation_Middleware(t *testing.T) {
+	var spanCtx trace.SpanContext
+	g, exp := newGateway(func(w http.ResponseWriter, r *http.Request) {
+		spanCtx = trace.SpanContextFromContext(r.Context())
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	ev := events.APIGatewayProxyRequest{
+		Resource:   "/pets/{id}",
+		Path:       "/pets/luna",
+		HTTPMethod: "GET",
+		Headers: map[string]string{
+			"traceparent": "00-5759e988bd862e3fe1be46a994272793-53995c3f42cd8ad8-01",
+		},
+		RequestContext: events.APIGatewayProxyRequestContext{
+			RequestID: "c6af9ac6-7b61-11e6-9a41-93e8deadbeef",
+		},
+	}
+
+	resp, err := g.Serve(context.TODO(), ev)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+
+	spans := exp.GetSpans()
+	if !assert.Len(t, spans, 1) {
+		return
+	}
+	s := spans[0]
+	assert.Equal(t, "GET /pets/{id}", s.Name)
+	assert.Equal(t, trace.SpanKindServer, s.SpanKind)
+	assert.Equal(t, "5759e988bd862e3fe1be46a994272793", s.SpanContext.TraceID().String())
+	assert.Equal(t, "53995c3f42cd8ad8", s.Parent.SpanID().String())
+	assert.Equal(t, s.SpanContext.SpanID(), spanCtx.SpanID())
+
+	a := attrs(s)
+	assert.Equal(t, "/pets/{id}", a["http.route"].AsString())
+	assert.Equal(t, "GET", a["http.request.method"].AsString())
+	assert.Equal(t, int64(http.StatusTeapot), a["http.response.status_code"].AsInt64())
+	assert.Equal(t, "c6af9ac6-7b61-11e6-9a41-93e8deadbeef", a["aws.request_id"].AsString())
+	assert.Equal(t, "http", a["faas.trigger"].AsString())
+	assert.True(t, a["faas.coldstart"].AsBool())
+}
+
+func TestInstrumentation_Middleware_coldStart(t *testing.T) {
+	g, exp := newGateway(func(w http.ResponseWriter, r *http.Request) {})
+	_, err := g.Serve(context.TODO(), events.APIGatewayProxyRequest{HTTPMethod: "GET"})
+	assert.NoError(t, err)
+	exp.Reset()
+	_, err = g.Serve(context.TODO(), events.APIGatewayProxyRequest{HTTPMethod: "GET"})
+	assert.NoError(t, err)
+
+	// Cold start is tracked by each Gateway.
+	other, otherExp := newGateway(func(w http.ResponseWriter, r *http.Request) {})
+	_, err = other.Serve(context.TODO(), events.APIGatewayProxyRequest{HTTPMethod: "GET"})
+	assert.NoError(t, err)
+
+	if spans := exp.GetSpans(); assert.Len(t, spans, 1) {
+		assert.False(t, attrs(spans[0])["faas.coldstart"].AsBool())
+	}
+	if spans := otherExp.GetSpans(); assert.Len(t, spans, 1) {
+		assert.True(t, attrs(spans[0])["faas.coldstart"].AsBool())
+	}
+}
+
+func TestInstrumentation_Middleware_serverError(t *testing.T) {
+	g, exp := newGateway(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	_, err := g.Serve(context.TODO(), events.APIGatewayProxyRequest{HTTPMethod: "GET"})
+	assert.NoError(t, err)
+
+	spans := exp.GetSpans()
+	if assert.Len(t, spans, 1) {
+		assert.Equal(t, codes.Error, spans[0].Status.Code)
+	}
+}
+
+func TestInstrumentation_Middleware_panic(t *testing.T) {
+	g, exp := newGateway(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	assert.PanicsWithValue(t, "boom", func() {
+		g.Serve(context.TODO(), events.APIGatewayProxyRequest{HTTPMethod: "GET"})
+	})
+
+	spans := exp.GetSpans()
+	if assert.Len(t, spans, 1) {
+		assert.Equal(t, codes.Error, spans[0].Status.Code)
+		if assert.Len(t, spans[0].Events, 1) {
+			assert.Equal(t, "exception", spans[0].Events[0].Name)
+		}
+	}
+}
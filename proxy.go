@@ -26,13 +26,25 @@ func (f ProxyFunc) Transform(ctx context.Context, ev events.APIGatewayProxyReque
 // DefaultProxy is a default proxy for AWS API Gateway events
 type DefaultProxy struct {
 	Host string
+	// HostResolver determines the host from the event. Host is used whether
+	// HostResolver is nil or it could not resolve the host.
+	HostResolver HostResolver
+	// ClientIPResolver determines the RemoteAddr of the request, the
+	// source IP of the event is used whether it is nil.
+	ClientIPResolver ClientIPResolver
+	// RawQueryResolver determines the original query string, which is
+	// used verbatim. Query string is encoded from the event (with sorted
+	// parameters) whether it is nil or the query could not be resolved.
+	RawQueryResolver RawQueryResolver
 }
 
 // Transform returns a new http.Request created from the given Lambda event.
 func (p *DefaultProxy) Transform(ctx context.Context, ev events.APIGatewayProxyRequest) (*http.Request, error) {
 	r := NewRequest(ctx, ev)
+	r.ResolveClientIP(p.ClientIPResolver)
+	r.ResolveRawQuery(p.RawQueryResolver)
 
-	req, err := r.CreateRequest(p.Host)
+	req, err := r.CreateRequest(resolveHost(p.HostResolver, p.Host, ev))
 	if err != nil {
 		return nil, errors.Wrap(err, "creating request")
 	}
@@ -48,15 +60,53 @@ func (p *DefaultProxy) Transform(ctx context.Context, ev events.APIGatewayProxyR
 }
 
 type StripBasePathProxy struct {
-	Host     string
-	BasePath string
+	Host             string
+	BasePath         string
+	HostResolver     HostResolver
+	ClientIPResolver ClientIPResolver
+	RawQueryResolver RawQueryResolver
 }
 
 func (p *StripBasePathProxy) Transform(ctx context.Context, ev events.APIGatewayProxyRequest) (*http.Request, error) {
 	r := NewRequest(ctx, ev)
+	r.ResolveClientIP(p.ClientIPResolver)
+	r.ResolveRawQuery(p.RawQueryResolver)
 	r.StripBasePath(p.BasePath)
 
-	req, err := r.CreateRequest(p.Host)
+	req, err := r.CreateRequest(resolveHost(p.HostResolver, p.Host, ev))
+	if err != nil {
+		return nil, errors.Wrap(err, "creating request")
+	}
+
+	r.AttachContext(req)
+	r.SetRemoteAddr(req)
+	r.SetHeaderFields(req)
+	r.SetContentLength(req)
+	r.SetCustomHeaders(req)
+	r.SetXRayHeader(req)
+
+	return req, nil
+}
+
+// DetectBasePathProxy is a proxy which strips out a base path (Base Path
+// Mapping of the Custom Domain Name or a stage) detected from the event.
+// Detected base path is available via BasePath function.
+type DetectBasePathProxy struct {
+	Host             string
+	HostResolver     HostResolver
+	ClientIPResolver ClientIPResolver
+	RawQueryResolver RawQueryResolver
+}
+
+// Transform returns a new http.Request created from the given Lambda event
+// without a detected base path in the URL.
+func (p *DetectBasePathProxy) Transform(ctx context.Context, ev events.APIGatewayProxyRequest) (*http.Request, error) {
+	r := NewRequest(ctx, ev)
+	r.ResolveClientIP(p.ClientIPResolver)
+	r.ResolveRawQuery(p.RawQueryResolver)
+	r.DetectBasePath()
+
+	req, err := r.CreateRequest(resolveHost(p.HostResolver, p.Host, ev))
 	if err != nil {
 		return nil, errors.Wrap(err, "creating request")
 	}
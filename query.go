@@ -0,0 +1,86 @@
+package apigo
+
+import (
+	"net/url"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// RawQueryResolver determines the encoded query string (without '?') of the
+// original request, which the API Gateway does not provide in the event.
+// It allows to keep the order and the encoding of the parameters, i.e. for
+// handlers which verify signatures of the URL.
+type RawQueryResolver interface {
+	ResolveRawQuery(events.APIGatewayProxyRequest) string
+}
+
+// RawQueryResolverFunc implements the RawQueryResolver interface to allow use
+// of ordinary function as a resolver.
+type RawQueryResolverFunc func(events.APIGatewayProxyRequest) string
+
+// ResolveRawQuery calls f(ev).
+func (f RawQueryResolverFunc) ResolveRawQuery(ev events.APIGatewayProxyRequest) string {
+	return f(ev)
+}
+
+// HeaderRawQuery returns a RawQueryResolver which takes the query string
+// from the header, i.e. forwarded by a CloudFront Function or Lambda@Edge in
+// front of the API Gateway.
+func HeaderRawQuery(name string) RawQueryResolver {
+	return RawQueryResolverFunc(func(ev events.APIGatewayProxyRequest) string {
+		return eventHeader(ev, name)
+	})
+}
+
+// ResolveRawQuery determines the encoded query string of the original request
+// using rq. The query string is used only whether it decodes to the same
+// parameters as the event provides, otherwise the query string is encoded
+// from the event. ResolveRawQuery must be run before
+// RequestBuilder.ParseURL function.
+func (r *Request) ResolveRawQuery(rq RawQueryResolver) {
+	if rq == nil {
+		return
+	}
+	raw := rq.ResolveRawQuery(r.Event)
+	if raw != "" && matchQuery(raw, r.Event) {
+		r.RawQuery = raw
+	}
+}
+
+// matchQuery reports whether the raw query string decodes to the query
+// string parameters of the event.
+func matchQuery(raw string, ev events.APIGatewayProxyRequest) bool {
+	q, err := url.ParseQuery(raw)
+	if err != nil {
+		return false
+	}
+
+	if len(ev.MultiValueQueryStringParameters) == 0 {
+		if len(q) != len(ev.QueryStringParameters) {
+			return false
+		}
+		for k, v := range ev.QueryStringParameters {
+			vs := q[k]
+			if len(vs) == 0 || vs[len(vs)-1] != v {
+				return false
+			}
+		}
+		return true
+	}
+
+	if len(q) != len(ev.MultiValueQueryStringParameters) {
+		return false
+	}
+	for k, want := range ev.MultiValueQueryStringParameters {
+		got := q[k]
+		if len(got) != len(want) {
+			return false
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				return false
+			}
+		}
+	}
+	return true
+}
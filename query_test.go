@@ -0,0 +1,97 @@
+package apigo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequest_ResolveRawQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		event  events.APIGatewayProxyRequest
+		header string
+		want   string
+	}{
+		{
+			name: "Ordered",
+			event: events.APIGatewayProxyRequest{
+				MultiValueQueryStringParameters: map[string][]string{
+					"tag": {"b", "a"},
+					"q":   {"a b"},
+				},
+			},
+			header: "tag=b&q=a%20b&tag=a",
+			want:   "tag=b&q=a%20b&tag=a",
+		},
+		{
+			name: "SingleValue",
+			event: events.APIGatewayProxyRequest{
+				QueryStringParameters: map[string]string{"tag": "a", "x": "/"},
+			},
+			header: "x=/&tag=b&tag=a",
+			want:   "x=/&tag=b&tag=a",
+		},
+		{
+			name: "Mismatch",
+			event: events.APIGatewayProxyRequest{
+				MultiValueQueryStringParameters: map[string][]string{"tag": {"a", "b"}},
+			},
+			header: "tag=b&tag=a",
+			want:   "tag=a&tag=b",
+		},
+		{
+			name: "Extra",
+			event: events.APIGatewayProxyRequest{
+				QueryStringParameters: map[string]string{"tag": "a"},
+			},
+			header: "tag=a&admin=1",
+			want:   "tag=a",
+		},
+		{
+			name: "Invalid",
+			event: events.APIGatewayProxyRequest{
+				QueryStringParameters: map[string]string{"q": "%zz"},
+			},
+			header: "q=%zz",
+			want:   "q=%25zz",
+		},
+		{
+			name:  "Missing",
+			event: events.APIGatewayProxyRequest{QueryStringParameters: map[string]string{"q": "a"}},
+			want:  "q=a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev := tt.event
+			ev.Path = "/pets"
+			if tt.header != "" {
+				ev.Headers = map[string]string{"X-Raw-Query": tt.header}
+			}
+
+			p := &DefaultProxy{RawQueryResolver: HeaderRawQuery("x-raw-query")}
+			r, err := p.Transform(context.TODO(), ev)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, r.URL.RawQuery)
+			assert.Equal(t, "/pets?"+tt.want, r.RequestURI)
+		})
+	}
+}
+
+func TestRawQueryResolverFunc(t *testing.T) {
+	rq := RawQueryResolverFunc(func(ev events.APIGatewayProxyRequest) string {
+		return "b=2&a=1"
+	})
+
+	ev := events.APIGatewayProxyRequest{
+		Path:                  "/",
+		QueryStringParameters: map[string]string{"a": "1", "b": "2"},
+	}
+	r, err := (&StripBasePathProxy{BasePath: "/", RawQueryResolver: rq}).Transform(context.TODO(), ev)
+	assert.NoError(t, err)
+	assert.Equal(t, "b=2&a=1", r.URL.RawQuery)
+}
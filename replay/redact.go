@@ -0,0 +1,274 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"mime"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Redacted replaces values of redacted fields.
+const Redacted = "REDACTED"
+
+// Redaction defines which data is redacted from the records.
+//
+// Fields are redacted in JSON and form-encoded (URL-encoded) bodies, also
+// when they are base64 encoded. Other bodies (i.e. multipart forms, XML or
+// binary data) are recorded as they are.
+type Redaction struct {
+	// Headers are names of request and response headers to redact.
+	Headers []string
+	// QueryParameters are names of query string parameters to redact.
+	QueryParameters []string
+	// BodyFields are names of fields (at any depth) to redact in bodies of
+	// requests and responses and in claims of the authorizer's context.
+	BodyFields []string
+	// Identity redacts the caller's identity (source IP, user, ARN etc.)
+	// in the Request Context.
+	Identity bool
+	// Authorizer redacts all values of the authorizer's context (i.e. JWT
+	// claims) in the Request Context, not only the BodyFields.
+	Authorizer bool
+}
+
+// DefaultRedaction redacts credentials, cookies and common PII fields.
+var DefaultRedaction = Redaction{
+	Headers: []string{
+		"Authorization", "Cookie", "Set-Cookie", "X-Api-Key",
+		"X-Amz-Security-Token", "Proxy-Authorization",
+	},
+	QueryParameters: []string{"token", "access_token", "api_key"},
+	BodyFields: []string{
+		"password", "token", "access_token", "refresh_token", "secret",
+		"email", "phone", "ssn", "card_number",
+	},
+	Identity: true,
+}
+
+// Apply returns a copy of the record with redacted data.
+func (red *Redaction) Apply(r Record) Record {
+	ev := r.Event
+	ev.Headers = red.redactMap(ev.Headers, red.Headers, true)
+	ev.MultiValueHeaders = red.redactMultiMap(ev.MultiValueHeaders, red.Headers, true)
+	ev.QueryStringParameters = red.redactMap(ev.QueryStringParameters, red.QueryParameters, false)
+	ev.MultiValueQueryStringParameters = red.redactMultiMap(ev.MultiValueQueryStringParameters, red.QueryParameters, false)
+	ev.Body = red.redactBody(ev.Body, ev.IsBase64Encoded, headerValue(ev.Headers, ev.MultiValueHeaders, "Content-Type"))
+	ev.RequestContext.Authorizer = red.redactAuthorizer(ev.RequestContext.Authorizer)
+
+	if red.Identity {
+		id := &ev.RequestContext.Identity
+		for _, f := range []*string{
+			&id.SourceIP, &id.User, &id.UserArn, &id.Caller, &id.AccountID,
+			&id.CognitoIdentityID, &id.CognitoAuthenticationProvider, &id.APIKey, &id.AccessKey,
+		} {
+			if *f != "" {
+				*f = Redacted
+			}
+		}
+		id.ClientCert = nil
+	}
+	r.Event = ev
+	r.Response = red.ApplyResponse(r.Response)
+
+	return r
+}
+
+// ApplyResponse returns a copy of the response with redacted data.
+func (red *Redaction) ApplyResponse(resp events.APIGatewayProxyResponse) events.APIGatewayProxyResponse {
+	resp.Headers = red.redactMap(resp.Headers, red.Headers, true)
+	resp.MultiValueHeaders = red.redactMultiMap(resp.MultiValueHeaders, red.Headers, true)
+	resp.Body = red.redactBody(resp.Body, resp.IsBase64Encoded, headerValue(resp.Headers, resp.MultiValueHeaders, "Content-Type"))
+	return resp
+}
+
+// redactAuthorizer returns a copy of the authorizer's context with
+// redacted values.
+func (red *Redaction) redactAuthorizer(m map[string]interface{}) map[string]interface{} {
+	if len(m) == 0 {
+		return m
+	}
+
+	out := make(map[string]interface{}, len(m))
+	if red.Authorizer {
+		for k := range m {
+			out[k] = Redacted
+		}
+		return out
+	}
+
+	// Values are copied through JSON, as they are redacted in place.
+	b, err := json.Marshal(m)
+	if err != nil {
+		return m
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&out); err != nil {
+		return m
+	}
+	if !red.redactValue(out) {
+		return m
+	}
+	return out
+}
+
+// redactMap returns a copy of m with redacted values of the names.
+func (red *Redaction) redactMap(m map[string]string, names []string, fold bool) map[string]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		if matchName(k, names, fold) {
+			v = Redacted
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// redactMultiMap returns a copy of m with redacted values of the names.
+func (red *Redaction) redactMultiMap(m map[string][]string, names []string, fold bool) map[string][]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string][]string, len(m))
+	for k, vs := range m {
+		if matchName(k, names, fold) {
+			redacted := make([]string, len(vs))
+			for i := range redacted {
+				redacted[i] = Redacted
+			}
+			vs = redacted
+		}
+		out[k] = vs
+	}
+	return out
+}
+
+// redactBody redacts fields of the JSON or form-encoded body, other bodies
+// are returned unchanged.
+func (red *Redaction) redactBody(body string, isBase64 bool, contentType string) string {
+	if len(red.BodyFields) == 0 || body == "" {
+		return body
+	}
+
+	if !isBase64 {
+		if redacted, ok := red.redactText(body, contentType); ok {
+			return redacted
+		}
+		return body
+	}
+
+	b, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return body
+	}
+	if redacted, ok := red.redactText(string(b), contentType); ok {
+		return base64.StdEncoding.EncodeToString([]byte(redacted))
+	}
+	return body
+}
+
+// redactText redacts fields of the body and reports whether anything has
+// been redacted.
+func (red *Redaction) redactText(body, contentType string) (string, bool) {
+	if mt, _, _ := mime.ParseMediaType(contentType); mt == "application/x-www-form-urlencoded" {
+		return red.redactForm(body)
+	}
+	return red.redactJSON(body)
+}
+
+// redactJSON redacts fields of the JSON body.
+func (red *Redaction) redactJSON(body string) (string, bool) {
+	dec := json.NewDecoder(strings.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return body, false
+	}
+
+	if !red.redactValue(v) {
+		return body, false
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return body, false
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), true
+}
+
+// redactForm redacts fields of the form-encoded body, keeping the order of
+// the fields.
+func (red *Redaction) redactForm(body string) (string, bool) {
+	changed := false
+	pairs := strings.Split(body, "&")
+	for i, pair := range pairs {
+		key := pair
+		if j := strings.IndexByte(pair, '='); j >= 0 {
+			key = pair[:j]
+		}
+		name, err := url.QueryUnescape(key)
+		if err != nil || !matchName(name, red.BodyFields, true) {
+			continue
+		}
+		pairs[i] = key + "=" + Redacted
+		changed = true
+	}
+	return strings.Join(pairs, "&"), changed
+}
+
+// redactValue redacts fields of the decoded JSON value in place and reports
+// whether anything has been redacted.
+func (red *Redaction) redactValue(v interface{}) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, fv := range v {
+			if matchName(k, red.BodyFields, true) {
+				v[k] = Redacted
+				changed = true
+				continue
+			}
+			changed = red.redactValue(fv) || changed
+		}
+	case []interface{}:
+		for _, ev := range v {
+			changed = red.redactValue(ev) || changed
+		}
+	}
+	return changed
+}
+
+// matchName reports whether name is one of names.
+func matchName(name string, names []string, fold bool) bool {
+	for _, n := range names {
+		if name == n || (fold && strings.EqualFold(name, n)) {
+			return true
+		}
+	}
+	return false
+}
+
+// headerValue returns a value of the header from single or multi-value
+// headers, regardless of the case of its name.
+func headerValue(single map[string]string, multi map[string][]string, name string) string {
+	for k, vs := range multi {
+		if strings.EqualFold(k, name) && len(vs) > 0 {
+			return vs[0]
+		}
+	}
+	for k, v := range single {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
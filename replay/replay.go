@@ -0,0 +1,255 @@
+// Package replay records invocations handled by the apigo.Gateway as JSON
+// Lines and replays them against a handler, so real traffic can be used for
+// regression testing.
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/piotrkubisa/apigo"
+	"github.com/pkg/errors"
+)
+
+// ErrNoWriter is returned by the Recorder whether its Writer is nil.
+var ErrNoWriter = errors.New("no writer")
+
+// Record is a single invocation, stored as one line of the JSONL file.
+type Record struct {
+	Time     time.Time                      `json:"time"`
+	Event    events.APIGatewayProxyRequest  `json:"event"`
+	Response events.APIGatewayProxyResponse `json:"response"`
+	Error    string                         `json:"error,omitempty"`
+}
+
+// Recorder is a Gateway middleware which appends each invocation to the
+// Writer as a JSON line. Sensitive data is redacted according to the
+// Redaction (DefaultRedaction whether it is nil) before the record is
+// written.
+type Recorder struct {
+	Writer    io.Writer
+	Redaction *Redaction
+	// ErrorLog specifies an optional logger for errors of writing records.
+	// The standard logger is used whether it is nil.
+	ErrorLog *log.Logger
+
+	mu sync.Mutex
+}
+
+// Middleware records invocations handled by the Gateway. Errors of writing
+// records are logged to the ErrorLog, they are not returned to the AWS
+// Lambda.
+func (rec *Recorder) Middleware(next apigo.ServeFunc) apigo.ServeFunc {
+	return func(ctx context.Context, ev events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		resp, err := next(ctx, ev)
+
+		r := Record{Time: time.Now().UTC(), Event: ev, Response: resp}
+		if err != nil {
+			r.Error = err.Error()
+		}
+		if werr := rec.Write(r); werr != nil {
+			rec.logf("replay: writing record: %v", werr)
+		}
+
+		return resp, err
+	}
+}
+
+// Write redacts the record and appends it to the Writer.
+func (rec *Recorder) Write(r Record) error {
+	if rec.Writer == nil {
+		return ErrNoWriter
+	}
+	r = redaction(rec.Redaction).Apply(r)
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	_, err = rec.Writer.Write(append(b, '\n'))
+	return err
+}
+
+// logf logs using ErrorLog of the Recorder or the standard logger.
+func (rec *Recorder) logf(format string, args ...interface{}) {
+	if rec.ErrorLog != nil {
+		rec.ErrorLog.Printf(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
+
+// redaction returns red or DefaultRedaction whether it is nil.
+func redaction(red *Redaction) *Redaction {
+	if red == nil {
+		return &DefaultRedaction
+	}
+	return red
+}
+
+// DefaultIgnoreHeaders are response headers, which are not compared during
+// the replay, because they change between invocations.
+var DefaultIgnoreHeaders = []string{"Date", "X-Request-Id", "Set-Cookie", "X-Amzn-Trace-Id"}
+
+// Diff describes a difference between the recorded and replayed response.
+type Diff struct {
+	// Line is a line number of the record in the JSONL file.
+	Line  int
+	Field string
+	Want  string
+	Got   string
+}
+
+// String returns a human-readable description of the difference.
+func (d Diff) String() string {
+	return fmt.Sprintf("line %d: %s: want %q, got %q", d.Line, d.Field, d.Want, d.Got)
+}
+
+// Report summarizes the replay.
+type Report struct {
+	Total int
+	Diffs []Diff
+}
+
+// OK reports whether all responses are equivalent to the recorded ones.
+func (r Report) OK() bool {
+	return len(r.Diffs) == 0
+}
+
+// Replayer re-executes recorded invocations and compares responses.
+type Replayer struct {
+	// Serve handles events, i.e. apigo.Gateway.Serve.
+	Serve apigo.ServeFunc
+	// IgnoreHeaders are response headers, which are not compared.
+	// DefaultIgnoreHeaders are used whether it is nil.
+	IgnoreHeaders []string
+	// Redaction is applied to the replayed responses before they are
+	// compared, so it must match the Redaction of the Recorder.
+	// DefaultRedaction is used whether it is nil.
+	Redaction *Redaction
+}
+
+// Replay re-executes all records read from r and reports differences in
+// status, headers and body of the responses.
+func (rp *Replayer) Replay(ctx context.Context, r io.Reader) (Report, error) {
+	var report Report
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for line := 1; s.Scan(); line++ {
+		if len(bytes.TrimSpace(s.Bytes())) == 0 {
+			continue
+		}
+
+		var rec Record
+		if err := json.Unmarshal(s.Bytes(), &rec); err != nil {
+			return report, errors.Wrapf(err, "decoding line %d", line)
+		}
+
+		report.Total++
+		resp, err := rp.Serve(ctx, rec.Event)
+
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != rec.Error {
+			report.Diffs = append(report.Diffs, Diff{line, "error", rec.Error, got})
+			continue
+		}
+
+		resp = redaction(rp.Redaction).ApplyResponse(resp)
+		report.Diffs = append(report.Diffs, rp.compare(line, rec.Response, resp)...)
+	}
+
+	return report, s.Err()
+}
+
+// compare returns differences between the recorded and replayed response.
+func (rp *Replayer) compare(line int, want, got events.APIGatewayProxyResponse) []Diff {
+	var diffs []Diff
+
+	if want.StatusCode != got.StatusCode {
+		diffs = append(diffs, Diff{line, "status", fmt.Sprint(want.StatusCode), fmt.Sprint(got.StatusCode)})
+	}
+
+	ignore := rp.IgnoreHeaders
+	if ignore == nil {
+		ignore = DefaultIgnoreHeaders
+	}
+	wh, gh := responseHeaders(want, ignore), responseHeaders(got, ignore)
+	for _, k := range headerKeys(wh, gh) {
+		w, g := strings.Join(wh[k], ", "), strings.Join(gh[k], ", ")
+		if w != g {
+			diffs = append(diffs, Diff{line, "header " + k, w, g})
+		}
+	}
+
+	if wb, gb := responseBody(want), responseBody(got); wb != gb {
+		diffs = append(diffs, Diff{line, "body", wb, gb})
+	}
+
+	return diffs
+}
+
+// responseHeaders returns headers of the response without ignored ones.
+func responseHeaders(resp events.APIGatewayProxyResponse, ignore []string) http.Header {
+	h := make(http.Header)
+	for k, vs := range resp.MultiValueHeaders {
+		for _, v := range vs {
+			h.Add(k, v)
+		}
+	}
+	for k, v := range resp.Headers {
+		if _, ok := h[http.CanonicalHeaderKey(k)]; !ok {
+			h.Set(k, v)
+		}
+	}
+	for _, k := range ignore {
+		h.Del(k)
+	}
+	return h
+}
+
+// headerKeys returns sorted names of headers from both h1 and h2.
+func headerKeys(h1, h2 http.Header) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, h := range []http.Header{h1, h2} {
+		for k := range h {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// responseBody returns the decoded body of the response.
+func responseBody(resp events.APIGatewayProxyResponse) string {
+	if !resp.IsBase64Encoded {
+		return resp.Body
+	}
+	b, err := base64.StdEncoding.DecodeString(resp.Body)
+	if err != nil {
+		return resp.Body
+	}
+	return string(b)
+}
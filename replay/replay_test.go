@@ -0,0 +1,209 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func echo(ctx context.Context, ev events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers:    map[string]string{"Content-Type": "application/json", "Date": "now"},
+		MultiValueHeaders: map[string][]string{
+			"Set-Cookie": {"session=secret"},
+		},
+		Body: ev.Body,
+	}, nil
+}
+
+func TestRecorder_Middleware(t *testing.T) {
+	var buf bytes.Buffer
+	rec := &Recorder{Writer: &buf}
+
+	ev := events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Path:       "/users",
+		Headers: map[string]string{
+			"authorization": "Bearer xyz",
+			"Content-Type":  "application/json",
+		},
+		MultiValueHeaders: map[string][]string{
+			"Cookie": {"a=1", "b=2"},
+		},
+		QueryStringParameters: map[string]string{"token": "abc", "page": "2"},
+		Body:                  `{"name":"Luna","contact":{"email":"luna@example.com"},"tags":[{"password":"x"}]}`,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Identity: events.APIGatewayRequestIdentity{SourceIP: "1.2.3.4"},
+		},
+	}
+
+	resp, err := rec.Middleware(echo)(context.TODO(), ev)
+	assert.NoError(t, err)
+	assert.Equal(t, ev.Body, resp.Body)
+	assert.Equal(t, "Bearer xyz", ev.Headers["authorization"])
+
+	var r Record
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &r))
+	assert.Equal(t, 1, strings.Count(buf.String(), "\n"))
+
+	assert.Equal(t, Redacted, r.Event.Headers["authorization"])
+	assert.Equal(t, "application/json", r.Event.Headers["Content-Type"])
+	assert.Equal(t, []string{Redacted, Redacted}, r.Event.MultiValueHeaders["Cookie"])
+	assert.Equal(t, Redacted, r.Event.QueryStringParameters["token"])
+	assert.Equal(t, "2", r.Event.QueryStringParameters["page"])
+	assert.Equal(t, Redacted, r.Event.RequestContext.Identity.SourceIP)
+	assert.JSONEq(t, `{"name":"Luna","contact":{"email":"REDACTED"},"tags":[{"password":"REDACTED"}]}`, r.Event.Body)
+	assert.Equal(t, []string{Redacted}, r.Response.MultiValueHeaders["Set-Cookie"])
+	assert.Equal(t, 200, r.Response.StatusCode)
+}
+
+func TestRedaction_nonJSONBody(t *testing.T) {
+	red := &Redaction{BodyFields: []string{"email"}}
+	r := red.Apply(Record{Event: events.APIGatewayProxyRequest{Body: "email=luna@example.com"}})
+	assert.Equal(t, "email=luna@example.com", r.Event.Body)
+}
+
+func TestRedaction_formBody(t *testing.T) {
+	r := DefaultRedaction.Apply(Record{Event: events.APIGatewayProxyRequest{
+		Headers: map[string]string{"content-type": "application/x-www-form-urlencoded; charset=utf-8"},
+		Body:    "name=Luna&e%6Dail=luna%40example.com&password",
+	}})
+	assert.Equal(t, "name=Luna&e%6Dail=REDACTED&password=REDACTED", r.Event.Body)
+}
+
+func TestRedaction_base64Body(t *testing.T) {
+	r := DefaultRedaction.Apply(Record{Response: events.APIGatewayProxyResponse{
+		Headers:         map[string]string{"Content-Type": "application/json"},
+		Body:            base64.StdEncoding.EncodeToString([]byte(`{"email":"luna@example.com"}`)),
+		IsBase64Encoded: true,
+	}})
+	body, err := base64.StdEncoding.DecodeString(r.Response.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"email":"REDACTED"}`, string(body))
+
+	binary := base64.StdEncoding.EncodeToString([]byte{0, 1, 2})
+	r = DefaultRedaction.Apply(Record{Event: events.APIGatewayProxyRequest{Body: binary, IsBase64Encoded: true}})
+	assert.Equal(t, binary, r.Event.Body)
+}
+
+func TestRedaction_authorizer(t *testing.T) {
+	claims := map[string]interface{}{
+		"claims": map[string]interface{}{"sub": "123", "email": "luna@example.com"},
+	}
+	ev := events.APIGatewayProxyRequest{RequestContext: events.APIGatewayProxyRequestContext{Authorizer: claims}}
+
+	r := DefaultRedaction.Apply(Record{Event: ev})
+	assert.Equal(t, map[string]interface{}{
+		"claims": map[string]interface{}{"sub": "123", "email": Redacted},
+	}, r.Event.RequestContext.Authorizer)
+	assert.Equal(t, "luna@example.com", claims["claims"].(map[string]interface{})["email"])
+
+	r = (&Redaction{Authorizer: true}).Apply(Record{Event: ev})
+	assert.Equal(t, map[string]interface{}{"claims": Redacted}, r.Event.RequestContext.Authorizer)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestRecorder_Middleware_writeError(t *testing.T) {
+	var logs bytes.Buffer
+	rec := &Recorder{Writer: failingWriter{}, ErrorLog: log.New(&logs, "", 0)}
+
+	resp, err := rec.Middleware(echo)(context.TODO(), events.APIGatewayProxyRequest{Body: "ok"})
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", resp.Body)
+	assert.Equal(t, "replay: writing record: disk full\n", logs.String())
+}
+
+func TestRecorder_Write_noWriter(t *testing.T) {
+	assert.Equal(t, ErrNoWriter, new(Recorder).Write(Record{}))
+
+	var logs bytes.Buffer
+	rec := &Recorder{ErrorLog: log.New(&logs, "", 0)}
+	resp, err := rec.Middleware(echo)(context.TODO(), events.APIGatewayProxyRequest{Body: "ok"})
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", resp.Body)
+	assert.Equal(t, "replay: writing record: no writer\n", logs.String())
+}
+
+func TestReplayer_Replay_defaultRedaction(t *testing.T) {
+	profile := func(ctx context.Context, ev events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 200,
+			Headers:    map[string]string{"Content-Type": "application/json"},
+			Body:       `{"name":"Luna","email":"luna@example.com","token":"abc"}`,
+		}, nil
+	}
+
+	var buf bytes.Buffer
+	rec := &Recorder{Writer: &buf}
+	_, err := rec.Middleware(profile)(context.TODO(), events.APIGatewayProxyRequest{Path: "/me"})
+	assert.NoError(t, err)
+	assert.NotContains(t, buf.String(), "luna@example.com")
+
+	rp := &Replayer{Serve: profile}
+	report, err := rp.Replay(context.TODO(), bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Total)
+	assert.Empty(t, report.Diffs)
+}
+
+func TestReplayer_Replay(t *testing.T) {
+	var buf bytes.Buffer
+	rec := &Recorder{Writer: &buf}
+	serve := rec.Middleware(echo)
+
+	for _, body := range []string{`{"a":1}`, `{"b":2}`} {
+		_, err := serve(context.TODO(), events.APIGatewayProxyRequest{Body: body})
+		assert.NoError(t, err)
+	}
+
+	t.Run("Equal", func(t *testing.T) {
+		rp := &Replayer{Serve: echo}
+		report, err := rp.Replay(context.TODO(), bytes.NewReader(buf.Bytes()))
+		assert.NoError(t, err)
+		assert.Equal(t, 2, report.Total)
+		assert.True(t, report.OK())
+	})
+
+	t.Run("Diff", func(t *testing.T) {
+		changed := func(ctx context.Context, ev events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			resp, err := echo(ctx, ev)
+			if ev.Body == `{"b":2}` {
+				resp.StatusCode = 201
+				resp.Headers["Content-Type"] = "text/plain"
+				resp.Body = "changed"
+			}
+			return resp, err
+		}
+
+		rp := &Replayer{Serve: changed}
+		report, err := rp.Replay(context.TODO(), bytes.NewReader(buf.Bytes()))
+		assert.NoError(t, err)
+		assert.False(t, report.OK())
+		assert.Equal(t, []Diff{
+			{2, "status", "200", "201"},
+			{2, "header Content-Type", "application/json", "text/plain"},
+			{2, "body", `{"b":2}`, "changed"},
+		}, report.Diffs)
+		assert.Equal(t, `line 2: status: want "200", got "201"`, report.Diffs[0].String())
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		rp := &Replayer{Serve: echo}
+		_, err := rp.Replay(context.TODO(), strings.NewReader("{\n"))
+		assert.Error(t, err)
+	})
+}
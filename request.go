@@ -3,9 +3,7 @@ package apigo
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
 	"encoding/base64"
-	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -21,8 +19,15 @@ type Request struct {
 	Context context.Context
 	Event   events.APIGatewayProxyRequest
 
-	Path string
-	Body *bytes.Reader
+	Path     string
+	BasePath string
+	// RawQuery is an encoded query string (without '?') of the original
+	// request. Whether it is empty, query string is encoded from the
+	// parameters provided in the event.
+	RawQuery string
+	// ClientAddr is an address of the client, which may contain a port.
+	ClientAddr string
+	Body       *bytes.Reader
 }
 
 // NewRequest defines new RequestBuilder with context and event data
@@ -34,10 +39,33 @@ func NewRequest(ctx context.Context, ev events.APIGatewayProxyRequest) *Request
 	}
 }
 
+// ResolveClientIP determines an address of the client using cr (or SourceIP
+// if nil passed). ResolveClientIP must be run before
+// RequestBuilder.AttachContext and RequestBuilder.SetRemoteAddr functions.
+func (r *Request) ResolveClientIP(cr ClientIPResolver) {
+	if cr == nil {
+		cr = SourceIP
+	}
+	r.ClientAddr = cr.ResolveClientIP(r.Event)
+}
+
 // StripBasePath removes a BasePath from the Path fragment of the URL.
 // StripBasePath must be run before RequestBuilder.ParseURL function.
 func (r *Request) StripBasePath(basePath string) {
 	r.Path = omitBasePath(r.Event.Path, basePath)
+	if r.Path != r.Event.Path {
+		r.BasePath = "/" + strings.Trim(basePath, "/")
+	}
+}
+
+// DetectBasePath removes a base path (i.e. a Base Path Mapping of the Custom
+// Domain Name or a stage) from the Path fragment of the URL. The base path is
+// determined by comparing the path from the event with the resource path
+// populated with the path parameters.
+// DetectBasePath must be run before RequestBuilder.ParseURL function.
+func (r *Request) DetectBasePath() {
+	r.BasePath = detectBasePath(r.Event)
+	r.Path = omitBasePath(r.Event.Path, r.BasePath)
 }
 
 // omitBasePath strips out the base path from the given path.
@@ -45,13 +73,18 @@ func (r *Request) StripBasePath(basePath string) {
 // It allows to support both API endpoints (default, auto-generated
 // "execute-api" address and configured Base Path Mapping/ with a Custom Domain
 // Name), while preserving the same routing registered on the http.Handler.
+// Only whole segments of the path are stripped out.
 func omitBasePath(path string, basePath string) string {
+	basePath = strings.Trim(basePath, "/")
 	if path == "/" || basePath == "" {
 		return path
 	}
 
-	if strings.HasPrefix(path, "/"+basePath) {
-		path = strings.Replace(path, basePath, "", 1)
+	if path == "/"+basePath {
+		return "/"
+	}
+	if strings.HasPrefix(path, "/"+basePath+"/") {
+		path = path[len(basePath)+1:]
 	}
 	if strings.HasPrefix(path, "//") {
 		path = path[1:]
@@ -60,19 +93,98 @@ func omitBasePath(path string, basePath string) string {
 	return path
 }
 
+// detectBasePath returns a prefix of the event's path which precedes the
+// resource path. Whether resource path is not available or does not match
+// the path, then stage is considered as a base path if path starts with it.
+func detectBasePath(ev events.APIGatewayProxyRequest) string {
+	resource := ev.Resource
+	if resource == "" {
+		resource = ev.RequestContext.ResourcePath
+	}
+	if resource != "" {
+		if rp, ok := expandResourcePath(resource, ev.PathParameters); ok {
+			if prefix, ok := pathPrefix(ev.Path, rp); ok {
+				return prefix
+			}
+		}
+	}
+
+	stage := ev.RequestContext.Stage
+	if stage != "" && stage != "$default" {
+		if ev.Path == "/"+stage || strings.HasPrefix(ev.Path, "/"+stage+"/") {
+			return "/" + stage
+		}
+	}
+
+	return ""
+}
+
+// expandResourcePath replaces path parameters' placeholders (i.e. {id} or
+// {proxy+}) in the resource path with their values.
+func expandResourcePath(resource string, params map[string]string) (string, bool) {
+	segments := strings.Split(resource, "/")
+	for i, seg := range segments {
+		if !strings.HasPrefix(seg, "{") || !strings.HasSuffix(seg, "}") {
+			continue
+		}
+		name := strings.TrimSuffix(seg[1:len(seg)-1], "+")
+		v, ok := params[name]
+		if !ok {
+			return "", false
+		}
+		segments[i] = v
+	}
+	return strings.Join(segments, "/"), true
+}
+
+// pathPrefix returns a prefix of the path, which consists of whole segments
+// preceding the given suffix.
+func pathPrefix(path, suffix string) (string, bool) {
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	if len(suffix) > 1 {
+		suffix = strings.TrimSuffix(suffix, "/")
+	}
+
+	if suffix == "/" {
+		if path == "/" {
+			return "", true
+		}
+		return path, strings.HasPrefix(path, "/")
+	}
+	if !strings.HasSuffix(path, suffix) {
+		return "", false
+	}
+
+	prefix := path[:len(path)-len(suffix)]
+	if prefix != "" && !strings.HasPrefix(prefix, "/") {
+		return "", false
+	}
+	return prefix, true
+}
+
 // CreateRequest provides *http.Request to the RequestBuilder.
 func (r *Request) CreateRequest(host string) (*http.Request, error) {
 	if err := r.ParseBody(); err != nil {
 		return nil, err
 	}
 
-	uri := r.ParseURL(host).String()
-	req, err := http.NewRequest(r.Event.HTTPMethod, uri, r.Body)
+	req, err := http.NewRequest(r.Event.HTTPMethod, "/", r.Body)
 	if err != nil {
 		return nil, err
 	}
-	req.TLS = &tls.ConnectionState{}
-	req.RequestURI = uri
+
+	// URL is assigned as is, because formatting and parsing it again would
+	// treat a path starting with "//" as a host (i.e. "//evil.example.com").
+	u := r.ParseURL(host)
+	req.URL = u
+	req.Host = u.Host
+	// RequestURI is the unmodified request-target, as received by the
+	// http.Server (i.e. "/pets?limit=10").
+	req.RequestURI = u.RequestURI()
+
+	req.TLS = r.ConnectionState(host)
 
 	return req, nil
 }
@@ -86,20 +198,75 @@ func (r *Request) ParseURL(host string) *url.URL {
 		path = r.Event.Path
 	}
 
+	// Scheme defaults to https only when host is known, otherwise URL
+	// remains relative (i.e. events from the console or tests).
+	scheme := eventHeader(r.Event, "X-Forwarded-Proto")
+	if scheme == "" && host != "" {
+		scheme = DefaultScheme
+	}
+
 	// Parse URL to *url.URL
 	u := &url.URL{
-		Scheme: r.Event.Headers["X-Forwarded-Proto"],
-		Host:   host,
-		Path:   path,
+		Scheme:  scheme,
+		Host:    host,
+		Path:    path,
+		RawPath: rawPath(path, r.Event.RequestContext.Path),
 	}
 
-	// Query-string
-	q := url.Values(r.Event.MultiValueQueryStringParameters)
-	u.RawQuery = q.Encode()
+	// Query-string, whether it has been already defined then it is used
+	// verbatim to keep its original order and encoding.
+	u.RawQuery = r.RawQuery
+	if len(u.RawQuery) == 0 {
+		u.RawQuery = encodeQuery(r.Event)
+	}
 
 	return u
 }
 
+// rawPath returns an encoded form of the path, which is taken from the
+// original (percent-encoded) path of the request. The encoded path may be
+// prefixed with a stage or a base path, so only its whole segments which
+// decode to the path are considered. Empty string is returned whether the
+// encoded form could not be determined, then the default encoding of
+// url.URL is used.
+func rawPath(path, encoded string) string {
+	if !strings.Contains(encoded, "%") {
+		return ""
+	}
+
+	segments := strings.Split(encoded, "/")
+	for i := len(segments) - 1; i > 0; i-- {
+		raw := "/" + strings.Join(segments[i:], "/")
+		if p, err := url.PathUnescape(raw); err == nil && p == path {
+			// RawPath is only kept when it differs from the default
+			// encoding, as url.URL does.
+			if raw == (&url.URL{Path: path}).EscapedPath() {
+				return ""
+			}
+			return raw
+		}
+	}
+
+	return ""
+}
+
+// encodeQuery encodes query string parameters from the event. Parameters are
+// taken from MultiValueQueryStringParameters and fall back to the
+// QueryStringParameters whether the former has not been provided. The event
+// does not keep the order of the parameters, so they are sorted by key (the
+// order of the values of the same key is kept).
+func encodeQuery(ev events.APIGatewayProxyRequest) string {
+	if len(ev.MultiValueQueryStringParameters) > 0 {
+		return url.Values(ev.MultiValueQueryStringParameters).Encode()
+	}
+
+	q := make(url.Values, len(ev.QueryStringParameters))
+	for k, v := range ev.QueryStringParameters {
+		q.Set(k, v)
+	}
+	return q.Encode()
+}
+
 // ParseBody provides body of the request to the RequestBuilder.
 func (r *Request) ParseBody() error {
 	body := []byte(r.Event.Body)
@@ -114,23 +281,48 @@ func (r *Request) ParseBody() error {
 	return nil
 }
 
-// AttachContext attaches events' RequestContext to the http.Request.
+// AttachContext attaches events' RequestContext and a base path (if it has
+// been stripped) to the http.Request.
 func (r *Request) AttachContext(req *http.Request) {
-	*req = *req.WithContext(NewContext(r.Context, r.Event))
+	ctx := NewContext(r.Context, r.Event)
+	if r.BasePath != "" {
+		ctx = context.WithValue(ctx, basePathKey, r.BasePath)
+	}
+	if r.ClientAddr != "" {
+		ctx = context.WithValue(ctx, clientIPKey, hostIP(r.ClientAddr))
+	}
+	*req = *req.WithContext(ctx)
 }
 
-// SetRemoteAddr sets RemoteAddr to the request.
+// SetRemoteAddr sets RemoteAddr (in host:port form) to the request. Address
+// of the client is taken from the source IP of the event whether it has not
+// been resolved before.
 func (r *Request) SetRemoteAddr(req *http.Request) {
-	req.RemoteAddr = r.Event.RequestContext.Identity.SourceIP
+	addr := r.ClientAddr
+	if addr == "" {
+		addr = r.Event.RequestContext.Identity.SourceIP
+	}
+	req.RemoteAddr = remoteAddr(addr)
 }
 
-// SetHeaderFields sets headers to the request.
+// SetHeaderFields sets headers to the request. Both MultiValueHeaders and
+// Headers from the event are used, so events which provide only one of them
+// (i.e. built by tools or older integrations) are supported too. Host of the
+// request is taken from the Host header whether it has been provided.
 func (r *Request) SetHeaderFields(req *http.Request) {
-	for k, hs := range r.Event.MultiValueHeaders {
+	for k, hs := range EventHeaders(r.Event) {
 		for _, v := range hs {
 			req.Header.Add(k, v)
 		}
 	}
+
+	// Host header is used only whether no host has been resolved, so
+	// req.Host does not diverge from req.URL.Host (i.e. an execute-api
+	// domain in the header of the custom domain's request).
+	if host := req.Header.Get("Host"); host != "" && req.Host == "" {
+		req.Host = host
+	}
+	req.Header.Del("Host")
 }
 
 // SetContentLength sets Content-Length to the request if it has not been set.
@@ -147,9 +339,17 @@ func (r *Request) SetCustomHeaders(req *http.Request) {
 	req.Header.Set("X-Stage", r.Event.RequestContext.Stage)
 }
 
-// SetXRayHeader sets AWS X-Ray Trace ID from the event's context.
+// SetXRayHeader sets AWS X-Ray Trace ID and its W3C Trace Context
+// equivalent (traceparent) to the request. Trace is taken from the AWS Lambda
+// invocation (context or _X_AMZN_TRACE_ID environment variable) or the
+// event's headers and it is attached to the request's context, so it can be
+// obtained via Trace function.
 func (r *Request) SetXRayHeader(req *http.Request) {
-	if traceID := r.Context.Value("x-amzn-trace-id"); traceID != nil {
-		req.Header.Set("X-Amzn-Trace-Id", fmt.Sprintf("%v", traceID))
+	tc, ok := lambdaTrace(r.Context, req.Header)
+	if !ok {
+		return
 	}
+
+	tc.Inject(req.Header)
+	*req = *req.WithContext(context.WithValue(req.Context(), traceKey, tc))
 }
@@ -1,8 +1,9 @@
 package apigo
 
 import (
+	"io"
+
 	"context"
-	"io/ioutil"
 	"testing"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -65,7 +66,11 @@ func TestNewRequest_remoteAddr(t *testing.T) {
 	r, err := new(DefaultProxy).Transform(context.TODO(), e)
 	assert.NoError(t, err)
 
-	assert.Equal(t, `1.2.3.4`, r.RemoteAddr)
+	assert.Equal(t, `1.2.3.4:0`, r.RemoteAddr)
+
+	ip, ok := ClientIP(r.Context())
+	assert.True(t, ok)
+	assert.Equal(t, `1.2.3.4`, ip)
 }
 
 func TestNewRequest_header(t *testing.T) {
@@ -109,7 +114,7 @@ func TestNewRequest_body(t *testing.T) {
 	r, err := new(DefaultProxy).Transform(context.TODO(), e)
 	assert.NoError(t, err)
 
-	b, err := ioutil.ReadAll(r.Body)
+	b, err := io.ReadAll(r.Body)
 	assert.NoError(t, err)
 
 	assert.Equal(t, `{ "name": "Tobi" }`, string(b))
@@ -126,7 +131,7 @@ func TestNewRequest_bodyBinary(t *testing.T) {
 	r, err := new(DefaultProxy).Transform(context.TODO(), e)
 	assert.NoError(t, err)
 
-	b, err := ioutil.ReadAll(r.Body)
+	b, err := io.ReadAll(r.Body)
 	assert.NoError(t, err)
 
 	assert.Equal(t, "hello world\n", string(b))
@@ -227,3 +232,201 @@ func TestStripBasePath_noBasePath(t *testing.T) {
 		assert.Equal(t, "/123", r.URL.Path)
 	})
 }
+
+func TestStripBasePath_wholeSegment(t *testing.T) {
+	assert.Equal(t, "/petsitter", omitBasePath("/petsitter", "pets"))
+	assert.Equal(t, "/petsitter/pets", omitBasePath("/petsitter/pets", "pets"))
+	assert.Equal(t, "/123", omitBasePath("/v1/pets/123", "/v1/pets"))
+	assert.Equal(t, "/", omitBasePath("/pets/", "pets"))
+}
+
+func TestDetectBasePath(t *testing.T) {
+	p := DetectBasePathProxy{Host: "api.example.com"}
+
+	tests := []struct {
+		name     string
+		event    events.APIGatewayProxyRequest
+		path     string
+		basePath string
+	}{
+		{
+			name: "ExecuteAPI",
+			event: events.APIGatewayProxyRequest{
+				Resource:       "/{id}",
+				Path:           "/123",
+				PathParameters: map[string]string{"id": "123"},
+				RequestContext: events.APIGatewayProxyRequestContext{Stage: "testing"},
+			},
+			path: "/123",
+		},
+		{
+			name: "CustomDomain",
+			event: events.APIGatewayProxyRequest{
+				Resource:       "/{id}",
+				Path:           "/pets/123",
+				PathParameters: map[string]string{"id": "123"},
+			},
+			path:     "/123",
+			basePath: "/pets",
+		},
+		{
+			name: "CustomDomainRoot",
+			event: events.APIGatewayProxyRequest{
+				Resource: "/",
+				Path:     "/pets",
+			},
+			path:     "/",
+			basePath: "/pets",
+		},
+		{
+			name: "Greedy",
+			event: events.APIGatewayProxyRequest{
+				Resource:       "/{proxy+}",
+				Path:           "/v1/pets/luna/toys",
+				PathParameters: map[string]string{"proxy": "pets/luna/toys"},
+			},
+			path:     "/pets/luna/toys",
+			basePath: "/v1",
+		},
+		{
+			name: "PartialSegment",
+			event: events.APIGatewayProxyRequest{
+				Resource: "/sitter",
+				Path:     "/petsitter",
+			},
+			path: "/petsitter",
+		},
+		{
+			name: "Stage",
+			event: events.APIGatewayProxyRequest{
+				Path:           "/testing/pets",
+				RequestContext: events.APIGatewayProxyRequestContext{Stage: "testing"},
+			},
+			path:     "/pets",
+			basePath: "/testing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := p.Transform(context.TODO(), tt.event)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.path, r.URL.Path)
+
+			bp, ok := BasePath(r.Context())
+			assert.Equal(t, tt.basePath != "", ok)
+			assert.Equal(t, tt.basePath, bp)
+		})
+	}
+}
+
+func TestNewRequest_rawPath(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		contextPath string
+		escaped     string
+	}{
+		{"EncodedSlash", "/files/a/b", "/testing/files/a%2Fb", "/files/a%2Fb"},
+		{"Space", "/files/a b", "/testing/files/a%20b", "/files/a%20b"},
+		{"Unicode", "/pets/łuna", "/pets/%C5%82una", "/pets/%C5%82una"},
+		{"Plus", "/pets/a+b", "/pets/a+b", "/pets/a+b"},
+		{"EncodedPlus", "/pets/a+b", "/pets/a%2Bb", "/pets/a%2Bb"},
+		{"NoContextPath", "/files/a b", "", "/files/a%20b"},
+		{"Mismatch", "/files/a b", "/testing/other/a%20c", "/files/a%20b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := events.APIGatewayProxyRequest{
+				Path: tt.path,
+				RequestContext: events.APIGatewayProxyRequestContext{
+					Path: tt.contextPath,
+				},
+			}
+
+			r, err := new(DefaultProxy).Transform(context.TODO(), e)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.path, r.URL.Path)
+			assert.Equal(t, tt.escaped, r.URL.EscapedPath())
+			assert.Equal(t, tt.escaped, r.RequestURI)
+		})
+	}
+}
+
+func TestNewRequest_queryStringSingleValue(t *testing.T) {
+	e := events.APIGatewayProxyRequest{
+		HTTPMethod: "GET",
+		Path:       "/pets",
+		QueryStringParameters: map[string]string{
+			"order":  "desc",
+			"fields": "name,species",
+		},
+	}
+
+	r, err := new(DefaultProxy).Transform(context.TODO(), e)
+	assert.NoError(t, err)
+
+	assert.Equal(t, `/pets?fields=name%2Cspecies&order=desc`, r.URL.String())
+	assert.Equal(t, `desc`, r.URL.Query().Get("order"))
+}
+
+func TestNewRequest_rawQuery(t *testing.T) {
+	e := events.APIGatewayProxyRequest{
+		HTTPMethod: "GET",
+		Path:       "/pets",
+		MultiValueQueryStringParameters: map[string][]string{
+			"order":  {"desc"},
+			"fields": {"name species"},
+		},
+	}
+
+	r := NewRequest(context.TODO(), e)
+	r.RawQuery = "order=desc&fields=name%20species"
+
+	req, err := r.CreateRequest("")
+	assert.NoError(t, err)
+
+	assert.Equal(t, `/pets?order=desc&fields=name%20species`, req.URL.String())
+	assert.Equal(t, `name species`, req.URL.Query().Get("fields"))
+}
+
+func TestNewRequest_headerFallback(t *testing.T) {
+	e := events.APIGatewayProxyRequest{
+		HTTPMethod: "GET",
+		Path:       "/pets",
+		Headers: map[string]string{
+			"host":              "api.example.com",
+			"content-type":      "application/json",
+			"x-forwarded-proto": "http",
+		},
+	}
+
+	r, err := new(DefaultProxy).Transform(context.TODO(), e)
+	assert.NoError(t, err)
+
+	assert.Equal(t, `api.example.com`, r.Host)
+	assert.Equal(t, `application/json`, r.Header.Get("Content-Type"))
+	assert.Equal(t, `http`, r.URL.Scheme)
+	assert.Empty(t, r.Header.Get("Host"))
+}
+
+func TestNewRequest_headerMerge(t *testing.T) {
+	e := events.APIGatewayProxyRequest{
+		HTTPMethod: "GET",
+		Path:       "/pets",
+		Headers: map[string]string{
+			"accept": "text/html",
+			"x-foo":  "baz",
+		},
+		MultiValueHeaders: map[string][]string{
+			"Accept": {"application/json", "text/html"},
+		},
+	}
+
+	r, err := new(DefaultProxy).Transform(context.TODO(), e)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"application/json", "text/html"}, r.Header["Accept"])
+	assert.Equal(t, []string{"baz"}, r.Header["X-Foo"])
+}
@@ -16,6 +16,7 @@ type ResponseWriter struct {
 	out           events.APIGatewayProxyResponse
 	buf           bytes.Buffer
 	header        http.Header
+	sent          http.Header
 	wroteHeader   bool
 	closeNotifyCh chan bool
 }
@@ -45,28 +46,15 @@ func (w *ResponseWriter) Write(b []byte) (int, error) {
 	return w.buf.Write(b)
 }
 
-// WriteHeader implementation.
+// WriteHeader implementation. Headers are captured at the time of the call,
+// so changes made afterwards are ignored (as in net/http).
 func (w *ResponseWriter) WriteHeader(status int) {
 	if w.wroteHeader {
 		return
 	}
 
-	if w.Header().Get("Content-Type") == "" {
-		w.Header().Set("Content-Type", "text/plain; charset=utf8")
-	}
-
 	w.out.StatusCode = status
-
-	h := make(map[string]string)
-
-	for k, v := range w.Header() {
-		if len(v) > 0 {
-			h[k] = v[len(v)-1]
-		}
-	}
-
-	w.out.Headers = h
-	w.out.MultiValueHeaders = map[string][]string(w.Header())
+	w.sent = w.Header().Clone()
 	w.wroteHeader = true
 }
 
@@ -75,9 +63,29 @@ func (w *ResponseWriter) CloseNotify() <-chan bool {
 	return w.closeNotifyCh
 }
 
-// End the request.
+// End the request. Status defaults to 200 OK whether it has not been
+// written and Content-Type is detected from the body whether it has not
+// been set (as in net/http).
 func (w *ResponseWriter) End() events.APIGatewayProxyResponse {
-	w.out.IsBase64Encoded = isBinary(w.header)
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	h := w.sent
+	if _, ok := h["Content-Type"]; !ok && w.buf.Len() > 0 {
+		h.Set("Content-Type", http.DetectContentType(w.buf.Bytes()))
+	}
+
+	w.out.Headers = make(map[string]string, len(h))
+	w.out.MultiValueHeaders = make(map[string][]string, len(h))
+	for k, v := range h {
+		if len(v) > 0 {
+			w.out.Headers[k] = v[len(v)-1]
+			w.out.MultiValueHeaders[k] = v
+		}
+	}
+
+	w.out.IsBase64Encoded = w.buf.Len() > 0 && isBinary(h)
 
 	if w.out.IsBase64Encoded {
 		w.out.Body = base64.StdEncoding.EncodeToString(w.buf.Bytes())
@@ -93,6 +93,23 @@ func TestResponseWriter_WriteHeader(t *testing.T) {
 	e := w.End()
 	assert.Equal(t, 404, e.StatusCode)
 	assert.Equal(t, "Not Found\n", e.Body)
-	assert.Equal(t, "text/plain; charset=utf8", e.Headers["Content-Type"])
-	assert.Equal(t, "text/plain; charset=utf8", e.MultiValueHeaders["Content-Type"][0])
+	assert.Equal(t, "text/plain; charset=utf-8", e.Headers["Content-Type"])
+	assert.Equal(t, "text/plain; charset=utf-8", e.MultiValueHeaders["Content-Type"][0])
+}
+
+func TestResponseWriter_WriteHeader_noBody(t *testing.T) {
+	w := NewResponse()
+	w.WriteHeader(204)
+	w.Header().Set("X-Late", "ignored")
+
+	e := w.End()
+	assert.Equal(t, 204, e.StatusCode)
+	assert.Equal(t, "", e.Body)
+	assert.Empty(t, e.Headers)
+	assert.False(t, e.IsBase64Encoded)
+}
+
+func TestResponseWriter_End_noWrite(t *testing.T) {
+	e := NewResponse().End()
+	assert.Equal(t, 200, e.StatusCode)
 }
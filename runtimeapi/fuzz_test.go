@@ -0,0 +1,84 @@
+package runtimeapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/piotrkubisa/apigo"
+	"github.com/stretchr/testify/assert"
+)
+
+// echoGateway creates the Gateway, which echoes requests.
+func echoGateway() *apigo.Gateway {
+	return apigo.NewGateway("api.example.com", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		// Trace is provided by the emulator only.
+		r.Header.Del("X-Amzn-Trace-Id")
+		r.Header.Del("Traceparent")
+		w.Header().Set("Content-Type", "application/octet-stream")
+		fmt.Fprintf(w, "%s %s %s %q\n", r.Method, r.URL.EscapedPath(), r.URL.RawQuery, r.Header)
+		w.Write(body)
+	}))
+}
+
+func FuzzServer_roundTrip(f *testing.F) {
+	f.Add("GET", "/pets", "name", "Luna", "Accept", "application/json", "", false)
+	f.Add("POST", "/files/a b/%2F", "q", "a&b=c", "Content-Type", "image/png", "aGVsbG8=", true)
+	f.Add("PUT", "/", "", "", "", "", "%", true)
+	f.Add("DELETE", "/łuna", "\xff", "\x00", "X-Custom", " ", "\xfe\xff", false)
+
+	// Gateway is run by the emulator in a subprocess shared by all fuzzing
+	// iterations and the expected response is served by its twin in the
+	// test process.
+	s := startGateway(f, "echo")
+	g := echoGateway()
+
+	f.Fuzz(func(t *testing.T, method, path, key, value, header, headerValue, body string, isBase64 bool) {
+
+		payload, err := json.Marshal(events.APIGatewayProxyRequest{
+			HTTPMethod:            method,
+			Path:                  path,
+			QueryStringParameters: map[string]string{key: value},
+			Headers:               map[string]string{header: headerValue},
+			Body:                  body,
+			IsBase64Encoded:       isBase64,
+			RequestContext: events.APIGatewayProxyRequestContext{
+				RequestID: "c6af9ac6-7b61-11e6-9a41-93e8deadbeef",
+			},
+		})
+		if err != nil {
+			t.Skip()
+		}
+
+		// Event is decoded from the payload, as strings which are not valid
+		// UTF-8 are altered by the encoding.
+		var ev events.APIGatewayProxyRequest
+		assert.NoError(t, json.Unmarshal(payload, &ev))
+		want, wantErr := g.Serve(context.Background(), ev)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		res, err := s.Invoke(ctx, payload)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		if wantErr != nil {
+			if assert.NotNil(t, res.Error) {
+				assert.Equal(t, wantErr.Error(), res.Error.ErrorMessage)
+			}
+			return
+		}
+
+		assert.Nil(t, res.Error)
+		var got events.APIGatewayProxyResponse
+		assert.NoError(t, json.Unmarshal(res.Payload, &got))
+		assert.Equal(t, want, got)
+	})
+}
@@ -0,0 +1,268 @@
+// Package runtimeapi provides a local emulator of the AWS Lambda Runtime API,
+// so Lambda functions (i.e. apigo.Gateway.ListenAndServe) can be run outside
+// the AWS Lambda. Function points to the emulator using the
+// AWS_LAMBDA_RUNTIME_API environment variable.
+package runtimeapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	runtimePrefix   = "/2018-06-01/runtime/"
+	extensionPrefix = "/2020-01-01/extension/"
+)
+
+// Defaults of the Server.
+const (
+	DefaultFunctionARN = "arn:aws:lambda:us-east-1:000000000000:function:apigo"
+	DefaultTimeout     = 30 * time.Second
+)
+
+// ErrorReport is an error reported by the function, or by the runtime when
+// it has failed to initialize.
+type ErrorReport struct {
+	ErrorMessage string          `json:"errorMessage"`
+	ErrorType    string          `json:"errorType"`
+	StackTrace   json.RawMessage `json:"stackTrace,omitempty"`
+}
+
+// Error implements the error interface.
+func (e *ErrorReport) Error() string {
+	if e.ErrorType == "" {
+		return e.ErrorMessage
+	}
+	return e.ErrorType + ": " + e.ErrorMessage
+}
+
+// Result is an outcome of the invocation. Either Payload or Error is set.
+type Result struct {
+	RequestID string
+	Payload   []byte
+	Error     *ErrorReport
+}
+
+// invocation is an event waiting to be handled by the function.
+type invocation struct {
+	id       string
+	payload  []byte
+	deadline time.Time
+	result   chan Result
+}
+
+// Server emulates the AWS Lambda Runtime API (next, response and error
+// endpoints) and registration of extensions. Server is a http.Handler, so
+// it can be served in-process (i.e. by httptest.Server) or on localhost.
+type Server struct {
+	// FunctionARN is an ARN of the function passed to the invocations.
+	FunctionARN string
+	// Timeout is used to compute a deadline of the invocations.
+	Timeout time.Duration
+
+	queue    chan *invocation
+	initErr  chan *ErrorReport
+	shutdown chan struct{}
+	closed   sync.Once
+	seq      int64
+
+	mu      sync.Mutex
+	pending map[string]*invocation
+}
+
+// NewServer creates new Server with default configuration.
+func NewServer() *Server {
+	return &Server{
+		FunctionARN: DefaultFunctionARN,
+		Timeout:     DefaultTimeout,
+		queue:       make(chan *invocation),
+		initErr:     make(chan *ErrorReport, 1),
+		shutdown:    make(chan struct{}),
+		pending:     make(map[string]*invocation),
+	}
+}
+
+// Invoke pushes the event payload to the function and waits for its
+// response or error report.
+func (s *Server) Invoke(ctx context.Context, payload []byte) (Result, error) {
+	inv := &invocation{
+		id:       fmt.Sprintf("00000000-0000-0000-0000-%012d", atomic.AddInt64(&s.seq, 1)),
+		payload:  payload,
+		deadline: time.Now().Add(s.Timeout),
+		result:   make(chan Result, 1),
+	}
+
+	s.mu.Lock()
+	s.pending[inv.id] = inv
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, inv.id)
+		s.mu.Unlock()
+	}()
+
+	select {
+	case s.queue <- inv:
+	case report := <-s.initErr:
+		return Result{}, errors.Wrap(report, "function initialization")
+	case <-ctx.Done():
+		return Result{}, ctx.Err()
+	}
+
+	select {
+	case res := <-inv.result:
+		return res, nil
+	case <-ctx.Done():
+		return Result{}, ctx.Err()
+	}
+}
+
+// InvokeFile pushes the event payload read from the file to the function.
+func (s *Server) InvokeFile(ctx context.Context, path string) (Result, error) {
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		return Result{}, err
+	}
+	return s.Invoke(ctx, payload)
+}
+
+// Close releases extensions waiting for the next event with the SHUTDOWN
+// event.
+func (s *Server) Close() {
+	s.closed.Do(func() { close(s.shutdown) })
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasPrefix(r.URL.Path, runtimePrefix):
+		s.serveRuntime(w, r, strings.TrimPrefix(r.URL.Path, runtimePrefix))
+	case strings.HasPrefix(r.URL.Path, extensionPrefix):
+		s.serveExtension(w, r, strings.TrimPrefix(r.URL.Path, extensionPrefix))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveRuntime handles endpoints of the Runtime API.
+func (s *Server) serveRuntime(w http.ResponseWriter, r *http.Request, path string) {
+	switch {
+	case path == "invocation/next" && r.Method == http.MethodGet:
+		s.next(w, r)
+	case path == "init/error" && r.Method == http.MethodPost:
+		report, err := decodeErrorReport(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		select {
+		case s.initErr <- report:
+		default:
+		}
+		w.WriteHeader(http.StatusAccepted)
+	case strings.HasPrefix(path, "invocation/") && r.Method == http.MethodPost:
+		parts := strings.Split(strings.TrimPrefix(path, "invocation/"), "/")
+		if len(parts) != 2 || (parts[1] != "response" && parts[1] != "error") {
+			http.NotFound(w, r)
+			return
+		}
+		s.complete(w, r, parts[0], parts[1] == "error")
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// next replies with the next invocation, it blocks until one is available.
+func (s *Server) next(w http.ResponseWriter, r *http.Request) {
+	var inv *invocation
+	select {
+	case inv = <-s.queue:
+	case <-r.Context().Done():
+		return
+	}
+
+	h := w.Header()
+	h.Set("Lambda-Runtime-Aws-Request-Id", inv.id)
+	h.Set("Lambda-Runtime-Deadline-Ms", strconv.FormatInt(inv.deadline.UnixNano()/int64(time.Millisecond), 10))
+	h.Set("Lambda-Runtime-Invoked-Function-Arn", s.FunctionARN)
+	h.Set("Lambda-Runtime-Trace-Id", "Root=1-00000000-000000000000000000000000;Parent=0000000000000000;Sampled=0")
+	h.Set("Content-Type", "application/json")
+	w.Write(inv.payload)
+}
+
+// complete records a response or an error report of the invocation.
+func (s *Server) complete(w http.ResponseWriter, r *http.Request, id string, failed bool) {
+	s.mu.Lock()
+	inv, ok := s.pending[id]
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, "unknown request ID", http.StatusBadRequest)
+		return
+	}
+
+	res := Result{RequestID: id}
+	if failed {
+		report, err := decodeErrorReport(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		res.Error = report
+	} else {
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		res.Payload = b
+	}
+
+	select {
+	case inv.result <- res:
+	default:
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// serveExtension handles endpoints of the Extensions API. Extensions are
+// accepted, but they get only the SHUTDOWN event when Server is closed.
+func (s *Server) serveExtension(w http.ResponseWriter, r *http.Request, path string) {
+	switch {
+	case path == "register" && r.Method == http.MethodPost:
+		w.Header().Set("Lambda-Extension-Identifier", r.Header.Get("Lambda-Extension-Name"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	case path == "event/next" && r.Method == http.MethodGet:
+		select {
+		case <-s.shutdown:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"eventType":"SHUTDOWN","shutdownReason":"spindown"}`))
+		case <-r.Context().Done():
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// decodeErrorReport decodes an error report from the request body.
+func decodeErrorReport(r *http.Request) (*ErrorReport, error) {
+	report := &ErrorReport{}
+	if err := json.NewDecoder(r.Body).Decode(report); err != nil {
+		return nil, errors.Wrap(err, "decoding error report")
+	}
+	if report.ErrorType == "" {
+		report.ErrorType = r.Header.Get("Lambda-Runtime-Function-Error-Type")
+	}
+	return report, nil
+}
@@ -0,0 +1,116 @@
+package runtimeapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/piotrkubisa/apigo"
+	"github.com/piotrkubisa/apigo/runtimeapi"
+	"github.com/stretchr/testify/assert"
+)
+
+// helperEnv selects the Gateway run by the test binary in a subprocess.
+const helperEnv = "APIGO_RUNTIMEAPI_HELPER"
+
+// gateways are run in subprocesses against the emulator, because the runtime
+// loop of the aws-lambda-go cannot be stopped and it exits the process once
+// the Runtime API is gone.
+var gateways = map[string]func() *apigo.Gateway{
+	"hello": helloGateway,
+	"echo":  echoGateway,
+}
+
+func TestMain(m *testing.M) {
+	if name := os.Getenv(helperEnv); name != "" {
+		gateways[name]().ListenAndServe()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// startGateway runs the Gateway of the given name in a subprocess against
+// a new emulator, both are stopped when the test finishes.
+func startGateway(t testing.TB, name string) *runtimeapi.Server {
+	s := runtimeapi.NewServer()
+	srv := httptest.NewServer(s)
+
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), helperEnv+"="+name, "AWS_LAMBDA_RUNTIME_API="+srv.Listener.Addr().String())
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		srv.Close()
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+		srv.CloseClientConnections()
+		srv.Close()
+	})
+	return s
+}
+
+func helloGateway() *apigo.Gateway {
+	return apigo.NewGateway("api.example.com", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `"Hello %s"`, r.URL.Query().Get("name"))
+	}))
+}
+
+func TestServer(t *testing.T) {
+	s := startGateway(t, "hello")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("Response", func(t *testing.T) {
+		res, err := s.InvokeFile(ctx, "testdata/hello.json")
+		assert.NoError(t, err)
+		assert.Nil(t, res.Error)
+
+		var resp events.APIGatewayProxyResponse
+		assert.NoError(t, json.Unmarshal(res.Payload, &resp))
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, `"Hello Luna"`, resp.Body)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		res, err := s.Invoke(ctx, []byte(`{"path": "/", "body": "%", "isBase64Encoded": true}`))
+		assert.NoError(t, err)
+		if assert.NotNil(t, res.Error) {
+			assert.Contains(t, res.Error.ErrorMessage, "decoding base64 body")
+			assert.NotEmpty(t, res.Error.ErrorType)
+		}
+	})
+}
+
+func TestServer_notFound(t *testing.T) {
+	srv := httptest.NewServer(runtimeapi.NewServer())
+	defer srv.Close()
+
+	res, err := http.Post(srv.URL+"/2018-06-01/runtime/invocation/unknown/response", "application/json", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	res, err = http.Get(srv.URL + "/2015-03-31/functions")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestServer_Invoke_canceled(t *testing.T) {
+	s := runtimeapi.NewServer()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := s.Invoke(ctx, []byte(`{}`))
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
@@ -0,0 +1,103 @@
+package apigo
+
+import (
+	"context"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// DefaultTaskTimeout is a maximum time to wait for background tasks whether
+// neither Gateway.TaskTimeout nor the invocation's deadline has been set, so
+// a stuck task does not block the Gateway forever.
+const DefaultTaskTimeout = 30 * time.Second
+
+type taskGroupContextKey struct{}
+
+var taskGroupKey = &taskGroupContextKey{}
+
+// taskGroup tracks background tasks started during an invocation.
+type taskGroup struct {
+	wg     sync.WaitGroup
+	report func(format string, args ...interface{})
+}
+
+// Go runs fn in a new goroutine, which is bound to the invocation handled by
+// the Gateway. Gateway waits for all tasks (up to Gateway.TaskTimeout)
+// before it returns a response, so tasks are not frozen by the AWS Lambda
+// in the middle of their work. Returned errors and panics are reported to
+// the Gateway.ErrorLog.
+//
+// Whether ctx does not come from the Gateway (i.e. in tests), fn is run in
+// a goroutine which is not waited for and its errors and panics are reported
+// to the standard logger.
+func Go(ctx context.Context, fn func(context.Context) error) {
+	tg, ok := ctx.Value(taskGroupKey).(*taskGroup)
+	if !ok {
+		go (&taskGroup{report: log.Printf}).run(ctx, fn)
+		return
+	}
+
+	tg.wg.Add(1)
+	go func() {
+		defer tg.wg.Done()
+		tg.run(ctx, fn)
+	}()
+}
+
+// run runs fn and reports its error or panic.
+func (tg *taskGroup) run(ctx context.Context, fn func(context.Context) error) {
+	defer func() {
+		if p := recover(); p != nil {
+			tg.report("apigo: panic in background task: %v\n%s", p, debug.Stack())
+		}
+	}()
+
+	if err := fn(ctx); err != nil {
+		tg.report("apigo: background task: %v", err)
+	}
+}
+
+// withTaskGroup returns a context, which tracks tasks started by Go.
+func (g *Gateway) withTaskGroup(ctx context.Context) (context.Context, *taskGroup) {
+	tg := &taskGroup{report: g.logf}
+	return context.WithValue(ctx, taskGroupKey, tg), tg
+}
+
+// taskTimeout returns a maximum time to wait for background tasks of the
+// invocation. Zero means the tasks are awaited until the ctx's deadline.
+func (g *Gateway) taskTimeout(ctx context.Context) time.Duration {
+	if g.TaskTimeout > 0 {
+		return g.TaskTimeout
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return 0
+	}
+	return DefaultTaskTimeout
+}
+
+// wait waits for all tasks to finish, up to the timeout (whether it is
+// greater than zero) or ctx to be done.
+func (tg *taskGroup) wait(ctx context.Context, timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		tg.wg.Wait()
+		close(done)
+	}()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		expired = t.C
+	}
+
+	select {
+	case <-done:
+	case <-expired:
+		tg.report("apigo: background tasks have not finished within %v", timeout)
+	case <-ctx.Done():
+		tg.report("apigo: background tasks have not finished: %v", ctx.Err())
+	}
+}
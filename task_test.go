@@ -0,0 +1,114 @@
+package apigo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGo(t *testing.T) {
+	var buf bytes.Buffer
+	var done int32
+
+	g := NewGateway("api.example.com", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Go(r.Context(), func(ctx context.Context) error {
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&done, 1)
+			return nil
+		})
+		Go(r.Context(), func(ctx context.Context) error {
+			return errors.New("slack unavailable")
+		})
+		Go(r.Context(), func(ctx context.Context) error {
+			panic("boom")
+		})
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	g.ErrorLog = log.New(&buf, "", 0)
+
+	resp, err := g.Serve(context.TODO(), events.APIGatewayProxyRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&done))
+	assert.Contains(t, buf.String(), "apigo: background task: slack unavailable")
+	assert.Contains(t, buf.String(), "apigo: panic in background task: boom")
+}
+
+func TestGo_timeout(t *testing.T) {
+	var buf bytes.Buffer
+	release := make(chan struct{})
+	defer close(release)
+
+	g := NewGateway("api.example.com", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Go(r.Context(), func(ctx context.Context) error {
+			<-release
+			return nil
+		})
+	}))
+	g.ErrorLog = log.New(&buf, "", 0)
+	g.TaskTimeout = 10 * time.Millisecond
+
+	_, err := g.Serve(context.TODO(), events.APIGatewayProxyRequest{})
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "apigo: background tasks have not finished within 10ms")
+}
+
+// chanWriter sends each write to the channel.
+type chanWriter chan string
+
+func (w chanWriter) Write(p []byte) (int, error) {
+	w <- string(p)
+	return len(p), nil
+}
+
+func TestGo_withoutGateway(t *testing.T) {
+	logs := make(chanWriter, 2)
+	log.SetOutput(logs)
+	log.SetFlags(0)
+	defer log.SetOutput(os.Stderr)
+	defer log.SetFlags(log.LstdFlags)
+
+	Go(context.TODO(), func(ctx context.Context) error {
+		return errors.New("boom")
+	})
+	assert.Equal(t, "apigo: background task: boom\n", <-logs)
+
+	Go(context.TODO(), func(ctx context.Context) error {
+		panic("oops")
+	})
+	assert.Contains(t, <-logs, "apigo: panic in background task: oops\n")
+}
+
+func TestGateway_taskTimeout(t *testing.T) {
+	g := new(Gateway)
+	assert.Equal(t, DefaultTaskTimeout, g.taskTimeout(context.TODO()))
+
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Minute)
+	defer cancel()
+	assert.Equal(t, time.Duration(0), g.taskTimeout(ctx))
+
+	g.TaskTimeout = time.Second
+	assert.Equal(t, time.Second, g.taskTimeout(context.TODO()))
+	assert.Equal(t, time.Second, g.taskTimeout(ctx))
+}
+
+func TestTaskGroup_wait_deadline(t *testing.T) {
+	var buf bytes.Buffer
+	tg := &taskGroup{report: log.New(&buf, "", 0).Printf}
+	tg.wg.Add(1)
+	defer tg.wg.Done()
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	tg.wait(ctx, 0)
+	assert.Equal(t, "apigo: background tasks have not finished: context deadline exceeded\n", buf.String())
+}
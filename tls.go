@@ -0,0 +1,78 @@
+package apigo
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"log"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ConnectionState returns a TLS connection state of the request created from
+// the event. Nil is returned whether the request has been forwarded over
+// plain HTTP (i.e. by a local stand-in of the API Gateway). Client
+// certificates provided by the API Gateway with mutual TLS are parsed into
+// PeerCertificates. Certificates which could not be parsed are logged and
+// left out, so the request can be rejected by the ClientCertVerifier or the
+// handler instead of failing the invocation.
+//
+// Version and CipherSuite are not provided in the event, so they remain
+// unset.
+func (r *Request) ConnectionState(host string) *tls.ConnectionState {
+	h := EventHeaders(r.Event)
+	for _, name := range []string{"X-Forwarded-Proto", "CloudFront-Forwarded-Proto"} {
+		if strings.EqualFold(h.Get(name), "http") {
+			return nil
+		}
+	}
+
+	if host == "" {
+		host = r.Event.RequestContext.DomainName
+	}
+	cs := &tls.ConnectionState{
+		HandshakeComplete: true,
+		ServerName:        host,
+	}
+
+	if cc := r.Event.RequestContext.Identity.ClientCert; cc != nil && cc.ClientCertPem != "" {
+		certs, err := ParseCertificates(cc.ClientCertPem)
+		if err != nil {
+			log.Printf("apigo: parsing client certificate: %v", err)
+		} else {
+			cs.PeerCertificates = certs
+		}
+	}
+
+	return cs
+}
+
+// ParseCertificates parses PEM encoded certificates (i.e. a client
+// certificate with its chain provided by the API Gateway with mutual TLS).
+func ParseCertificates(data string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, errors.New("no certificates found")
+	}
+	return certs, nil
+}
@@ -0,0 +1,113 @@
+package apigo
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"log"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// testCertificate returns a PEM encoded self-signed certificate.
+func testCertificate(t testing.TB, cn string) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestNewRequest_TLS(t *testing.T) {
+	e := events.APIGatewayProxyRequest{
+		Path: "/pets",
+		RequestContext: events.APIGatewayProxyRequestContext{
+			DomainName: "api.example.com",
+		},
+	}
+
+	r, err := new(DefaultProxy).Transform(context.TODO(), e)
+	assert.NoError(t, err)
+	if assert.NotNil(t, r.TLS) {
+		assert.True(t, r.TLS.HandshakeComplete)
+		assert.Equal(t, "api.example.com", r.TLS.ServerName)
+		assert.Empty(t, r.TLS.PeerCertificates)
+	}
+}
+
+func TestNewRequest_TLS_plainHTTP(t *testing.T) {
+	for _, name := range []string{"X-Forwarded-Proto", "CloudFront-Forwarded-Proto"} {
+		t.Run(name, func(t *testing.T) {
+			e := events.APIGatewayProxyRequest{
+				Path:    "/pets",
+				Headers: map[string]string{name: "http"},
+			}
+
+			r, err := new(DefaultProxy).Transform(context.TODO(), e)
+			assert.NoError(t, err)
+			assert.Nil(t, r.TLS)
+		})
+	}
+}
+
+func TestNewRequest_TLS_clientCert(t *testing.T) {
+	e := events.APIGatewayProxyRequest{
+		Path: "/pets",
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Identity: events.APIGatewayRequestIdentity{
+				ClientCert: &events.APIGatewayCustomAuthorizerRequestTypeRequestIdentityClientCert{
+					ClientCertPem: testCertificate(t, "client.example.com"),
+					SubjectDN:     "CN=client.example.com",
+				},
+			},
+		},
+	}
+
+	r, err := new(DefaultProxy).Transform(context.TODO(), e)
+	assert.NoError(t, err)
+	if assert.Len(t, r.TLS.PeerCertificates, 1) {
+		assert.Equal(t, "client.example.com", r.TLS.PeerCertificates[0].Subject.CommonName)
+	}
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	e.RequestContext.Identity.ClientCert.ClientCertPem = "invalid"
+	r, err = new(DefaultProxy).Transform(context.TODO(), e)
+	assert.NoError(t, err)
+	assert.NotNil(t, r.TLS)
+	assert.Empty(t, r.TLS.PeerCertificates)
+	assert.Contains(t, logs.String(), "apigo: parsing client certificate: no certificates found")
+
+	v, err := NewClientCertVerifier(testCertificate(t, "ca.example.com"))
+	assert.NoError(t, err)
+	_, err = v.Verify(r)
+	assert.Equal(t, ErrNoClientCert, err)
+}
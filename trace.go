@@ -0,0 +1,129 @@
+package apigo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// TraceContext describes a trace the request belongs to. It can be
+// translated between AWS X-Ray (X-Amzn-Trace-Id) and W3C Trace Context
+// (traceparent) header formats.
+type TraceContext struct {
+	// TraceID is an X-Ray trace ID (i.e. "1-5759e988-bd862e3fe1be46a994272793").
+	TraceID string
+	// ParentID is an ID of the parent segment (or span) as 16 hex digits.
+	ParentID string
+	// Sampled reports whether the trace has been sampled.
+	Sampled bool
+}
+
+// ParseXRayHeader parses a value of the X-Amzn-Trace-Id header (or
+// _X_AMZN_TRACE_ID environment variable).
+func ParseXRayHeader(s string) (TraceContext, bool) {
+	var tc TraceContext
+	for _, part := range strings.Split(s, ";") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "Root":
+			tc.TraceID = kv[1]
+		case "Parent":
+			tc.ParentID = kv[1]
+		case "Sampled":
+			tc.Sampled = kv[1] == "1"
+		}
+	}
+	return tc, isXRayTraceID(tc.TraceID)
+}
+
+// ParseTraceparent parses a value of the W3C traceparent header.
+func ParseTraceparent(s string) (TraceContext, bool) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return TraceContext{}, false
+	}
+	if !isHex(parts[1]) || !isHex(parts[2]) || !isHex(parts[3]) {
+		return TraceContext{}, false
+	}
+
+	tc := TraceContext{
+		TraceID:  "1-" + parts[1][:8] + "-" + parts[1][8:],
+		ParentID: parts[2],
+		Sampled:  parts[3][1]&1 == 1,
+	}
+	return tc, true
+}
+
+// XRayHeader returns a value of the X-Amzn-Trace-Id header.
+func (tc TraceContext) XRayHeader() string {
+	s := "Root=" + tc.TraceID
+	if tc.ParentID != "" {
+		s += ";Parent=" + tc.ParentID
+	}
+	if tc.Sampled {
+		return s + ";Sampled=1"
+	}
+	return s + ";Sampled=0"
+}
+
+// Traceparent returns a value of the W3C traceparent header. Empty string is
+// returned whether the parent ID is unknown, because it is required by the
+// W3C Trace Context.
+func (tc TraceContext) Traceparent() string {
+	if !isXRayTraceID(tc.TraceID) || len(tc.ParentID) != 16 {
+		return ""
+	}
+
+	flags := "00"
+	if tc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s%s-%s-%s", tc.TraceID[2:10], tc.TraceID[11:], tc.ParentID, flags)
+}
+
+// Inject sets both X-Amzn-Trace-Id and traceparent headers, so outbound
+// HTTP requests can propagate the same trace.
+func (tc TraceContext) Inject(h http.Header) {
+	h.Set("X-Amzn-Trace-Id", tc.XRayHeader())
+	if tp := tc.Traceparent(); tp != "" {
+		h.Set("Traceparent", tp)
+	}
+}
+
+// lambdaTrace returns a trace of the invocation provided by the AWS Lambda
+// (in context by aws-lambda-go or _X_AMZN_TRACE_ID environment variable) or
+// the trace from the event's headers.
+func lambdaTrace(ctx context.Context, h http.Header) (TraceContext, bool) {
+	if v, ok := ctx.Value("x-amzn-trace-id").(string); ok {
+		if tc, ok := ParseXRayHeader(v); ok {
+			return tc, true
+		}
+	}
+	if tc, ok := ParseXRayHeader(os.Getenv("_X_AMZN_TRACE_ID")); ok {
+		return tc, true
+	}
+	if tc, ok := ParseXRayHeader(h.Get("X-Amzn-Trace-Id")); ok {
+		return tc, true
+	}
+	return ParseTraceparent(h.Get("Traceparent"))
+}
+
+// isXRayTraceID reports whether id is in the "1-{8 hex}-{24 hex}" form.
+func isXRayTraceID(id string) bool {
+	return len(id) == 35 && id[:2] == "1-" && id[10] == '-' && isHex(id[2:10]) && isHex(id[11:])
+}
+
+// isHex reports whether s consists of lower-case hex digits only.
+func isHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,90 @@
+package apigo
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testXRayHeader  = "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1"
+	testTraceparent = "00-5759e988bd862e3fe1be46a994272793-53995c3f42cd8ad8-01"
+)
+
+func TestParseXRayHeader(t *testing.T) {
+	tc, ok := ParseXRayHeader(testXRayHeader)
+	assert.True(t, ok)
+	assert.Equal(t, "1-5759e988-bd862e3fe1be46a994272793", tc.TraceID)
+	assert.Equal(t, "53995c3f42cd8ad8", tc.ParentID)
+	assert.True(t, tc.Sampled)
+	assert.Equal(t, testXRayHeader, tc.XRayHeader())
+	assert.Equal(t, testTraceparent, tc.Traceparent())
+
+	tc, ok = ParseXRayHeader("Root=1-5759e988-bd862e3fe1be46a994272793")
+	assert.True(t, ok)
+	assert.Empty(t, tc.Traceparent())
+
+	_, ok = ParseXRayHeader("Root=invalid")
+	assert.False(t, ok)
+}
+
+func TestParseTraceparent(t *testing.T) {
+	tc, ok := ParseTraceparent(testTraceparent)
+	assert.True(t, ok)
+	assert.Equal(t, testXRayHeader, tc.XRayHeader())
+
+	_, ok = ParseTraceparent("00-xyz-53995c3f42cd8ad8-01")
+	assert.False(t, ok)
+}
+
+func TestTraceContext_Inject(t *testing.T) {
+	tc, _ := ParseXRayHeader(testXRayHeader)
+	h := http.Header{}
+	tc.Inject(h)
+
+	assert.Equal(t, testXRayHeader, h.Get("X-Amzn-Trace-Id"))
+	assert.Equal(t, testTraceparent, h.Get("Traceparent"))
+}
+
+func TestNewRequest_trace(t *testing.T) {
+	t.Run("LambdaContext", func(t *testing.T) {
+		ctx := context.WithValue(context.TODO(), "x-amzn-trace-id", testXRayHeader)
+		r, err := new(DefaultProxy).Transform(ctx, events.APIGatewayProxyRequest{})
+		assert.NoError(t, err)
+
+		assert.Equal(t, testXRayHeader, r.Header.Get("X-Amzn-Trace-Id"))
+		assert.Equal(t, testTraceparent, r.Header.Get("Traceparent"))
+
+		tc, ok := Trace(r.Context())
+		assert.True(t, ok)
+		assert.Equal(t, "1-5759e988-bd862e3fe1be46a994272793", tc.TraceID)
+	})
+
+	t.Run("Environment", func(t *testing.T) {
+		t.Setenv("_X_AMZN_TRACE_ID", testXRayHeader)
+		r, err := new(DefaultProxy).Transform(context.TODO(), events.APIGatewayProxyRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, testTraceparent, r.Header.Get("Traceparent"))
+	})
+
+	t.Run("Traceparent", func(t *testing.T) {
+		e := events.APIGatewayProxyRequest{
+			Headers: map[string]string{"traceparent": testTraceparent},
+		}
+		r, err := new(DefaultProxy).Transform(context.TODO(), e)
+		assert.NoError(t, err)
+		assert.Equal(t, testXRayHeader, r.Header.Get("X-Amzn-Trace-Id"))
+	})
+
+	t.Run("None", func(t *testing.T) {
+		r, err := new(DefaultProxy).Transform(context.TODO(), events.APIGatewayProxyRequest{})
+		assert.NoError(t, err)
+		assert.Empty(t, r.Header.Get("X-Amzn-Trace-Id"))
+
+		_, ok := Trace(r.Context())
+		assert.False(t, ok)
+	})
+}
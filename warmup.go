@@ -0,0 +1,51 @@
+package apigo
+
+import (
+	"encoding/json"
+)
+
+// WarmUpMatcher recognizes warm-up (keep-alive) events, which are sent to
+// keep the AWS Lambda execution environment warm (i.e. by
+// serverless-plugin-warmup or scheduled EventBridge rules).
+type WarmUpMatcher interface {
+	MatchWarmUp(payload []byte) bool
+}
+
+// WarmUpMatcherFunc implements the WarmUpMatcher interface to allow use of
+// ordinary function as a matcher.
+type WarmUpMatcherFunc func(payload []byte) bool
+
+// MatchWarmUp calls f(payload).
+func (f WarmUpMatcherFunc) MatchWarmUp(payload []byte) bool {
+	return f(payload)
+}
+
+// WarmUpSource returns a WarmUpMatcher which recognizes events by the value
+// of their "source" field.
+func WarmUpSource(sources ...string) WarmUpMatcher {
+	return WarmUpMatcherFunc(func(payload []byte) bool {
+		var ev struct {
+			Source string `json:"source"`
+		}
+		if err := json.Unmarshal(payload, &ev); err != nil || ev.Source == "" {
+			return false
+		}
+		for _, s := range sources {
+			if ev.Source == s {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// WarmUpMarker is a "source" of warm-up events recognized by the
+// DefaultWarmUp, i.e. set as a constant input of a scheduled EventBridge
+// rule: {"source": "apigo.warmup"}.
+const WarmUpMarker = "apigo.warmup"
+
+// DefaultWarmUp recognizes events sent by the serverless-plugin-warmup and
+// events marked with the WarmUpMarker. Events of EventBridge rules
+// ("aws.events" source) are not recognized, because they may be meant for
+// the handler.
+var DefaultWarmUp = WarmUpSource("serverless-plugin-warmup", WarmUpMarker)
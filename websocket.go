@@ -0,0 +1,116 @@
+package apigo
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Route keys of the events sent by the WebSocket API.
+const (
+	RouteConnect    = "$connect"
+	RouteDisconnect = "$disconnect"
+	RouteDefault    = "$default"
+)
+
+// WebsocketHandler handles an event from the AWS API Gateway WebSocket API.
+// Response body (if any) is sent back to the client whether the route has
+// a route response, a non-2xx status of the $connect route rejects the
+// connection.
+type WebsocketHandler interface {
+	ServeWebsocket(context.Context, events.APIGatewayWebsocketProxyRequest) (events.APIGatewayProxyResponse, error)
+}
+
+// WebsocketHandlerFunc implements the WebsocketHandler interface to allow use
+// of ordinary function as a handler.
+type WebsocketHandlerFunc func(context.Context, events.APIGatewayWebsocketProxyRequest) (events.APIGatewayProxyResponse, error)
+
+// ServeWebsocket calls f(ctx, ev).
+func (f WebsocketHandlerFunc) ServeWebsocket(ctx context.Context, ev events.APIGatewayWebsocketProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return f(ctx, ev)
+}
+
+// WebsocketMux dispatches events to handlers registered for their route
+// keys. Events of unknown routes are dispatched to the $default route
+// handler. $connect and $disconnect events are accepted whether their
+// handlers have not been registered. The zero value is ready to use.
+type WebsocketMux struct {
+	mu     sync.RWMutex
+	routes map[string]WebsocketHandler
+}
+
+// NewWebsocketMux creates new WebsocketMux.
+func NewWebsocketMux() *WebsocketMux {
+	return &WebsocketMux{routes: make(map[string]WebsocketHandler)}
+}
+
+// Handle registers the handler for the route key (i.e. "$connect" or
+// "sendMessage").
+func (m *WebsocketMux) Handle(routeKey string, h WebsocketHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.routes == nil {
+		m.routes = make(map[string]WebsocketHandler)
+	}
+	m.routes[routeKey] = h
+}
+
+// HandleFunc registers the handler function for the route key.
+func (m *WebsocketMux) HandleFunc(routeKey string, f func(context.Context, events.APIGatewayWebsocketProxyRequest) (events.APIGatewayProxyResponse, error)) {
+	m.Handle(routeKey, WebsocketHandlerFunc(f))
+}
+
+// ServeWebsocket dispatches the event to the handler of its route key.
+func (m *WebsocketMux) ServeWebsocket(ctx context.Context, ev events.APIGatewayWebsocketProxyRequest) (events.APIGatewayProxyResponse, error) {
+	routeKey := ev.RequestContext.RouteKey
+
+	m.mu.RLock()
+	h, ok := m.routes[routeKey]
+	if !ok && routeKey != RouteConnect && routeKey != RouteDisconnect {
+		h, ok = m.routes[RouteDefault]
+	}
+	m.mu.RUnlock()
+
+	if ok {
+		return h.ServeWebsocket(ctx, ev)
+	}
+	if routeKey == RouteConnect || routeKey == RouteDisconnect {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	}
+	return events.APIGatewayProxyResponse{StatusCode: http.StatusNotFound}, nil
+}
+
+// WebsocketGateway handles events from the AWS API Gateway WebSocket API
+// using Handler (i.e. WebsocketMux). Connection of the event is attached to
+// the context, so it can be obtained via WebsocketContext and ConnectionID
+// functions.
+type WebsocketGateway struct {
+	Handler WebsocketHandler
+}
+
+// NewWebsocketGateway creates new WebsocketGateway, which utilizes handler
+// as a WebsocketGateway.Handler.
+func NewWebsocketGateway(handler WebsocketHandler) *WebsocketGateway {
+	return &WebsocketGateway{Handler: handler}
+}
+
+// ListenAndServe registers a listener of AWS Lambda events.
+func (g *WebsocketGateway) ListenAndServe() {
+	lambda.Start(g.Serve)
+}
+
+// Serve handles the event and replies with a response. Status defaults to
+// 200 OK whether it has not been set by the Handler.
+func (g *WebsocketGateway) Serve(ctx context.Context, ev events.APIGatewayWebsocketProxyRequest) (events.APIGatewayProxyResponse, error) {
+	resp, err := g.Handler.ServeWebsocket(NewWebsocketContext(ctx, ev), ev)
+	if err != nil {
+		return resp, err
+	}
+	if resp.StatusCode == 0 {
+		resp.StatusCode = http.StatusOK
+	}
+	return resp, nil
+}
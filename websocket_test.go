@@ -0,0 +1,94 @@
+package apigo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func websocketEvent(routeKey string) events.APIGatewayWebsocketProxyRequest {
+	return events.APIGatewayWebsocketProxyRequest{
+		Body: `{"action":"sendMessage","data":"hello"}`,
+		RequestContext: events.APIGatewayWebsocketProxyRequestContext{
+			RouteKey:     routeKey,
+			ConnectionID: "L0SM9cOFvHcCIhw=",
+			DomainName:   "xxxxxxxxxx.execute-api.us-east-1.amazonaws.com",
+			Stage:        "prod",
+		},
+	}
+}
+
+func TestWebsocketGateway_Serve(t *testing.T) {
+	var routes []string
+	route := func(name string) WebsocketHandlerFunc {
+		return func(ctx context.Context, ev events.APIGatewayWebsocketProxyRequest) (events.APIGatewayProxyResponse, error) {
+			id, ok := ConnectionID(ctx)
+			assert.True(t, ok)
+			assert.Equal(t, "L0SM9cOFvHcCIhw=", id)
+
+			c, ok := WebsocketContext(ctx)
+			assert.True(t, ok)
+			assert.Equal(t, "prod", c.Stage)
+
+			routes = append(routes, name)
+			return events.APIGatewayProxyResponse{Body: name}, nil
+		}
+	}
+
+	mux := NewWebsocketMux()
+	mux.Handle(RouteConnect, route("connect"))
+	mux.Handle("sendMessage", route("send"))
+	g := NewWebsocketGateway(mux)
+
+	for key, status := range map[string]int{RouteConnect: 200, "sendMessage": 200, "unknown": 404, RouteDisconnect: 200} {
+		resp, err := g.Serve(context.TODO(), websocketEvent(key))
+		assert.NoError(t, err)
+		assert.Equal(t, status, resp.StatusCode, key)
+	}
+	assert.ElementsMatch(t, []string{"connect", "send"}, routes)
+
+	mux.Handle(RouteDefault, route("default"))
+	resp, err := g.Serve(context.TODO(), websocketEvent("unknown"))
+	assert.NoError(t, err)
+	assert.Equal(t, "default", resp.Body)
+
+	resp, err = g.Serve(context.TODO(), websocketEvent(RouteDisconnect))
+	assert.NoError(t, err)
+	assert.Equal(t, "", resp.Body)
+}
+
+func TestWebsocketMux_notFound(t *testing.T) {
+	resp, err := NewWebsocketMux().ServeWebsocket(context.TODO(), websocketEvent("sendMessage"))
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+}
+
+func TestWebsocketMux_zeroValue(t *testing.T) {
+	var mux WebsocketMux
+	resp, err := mux.ServeWebsocket(context.TODO(), websocketEvent("sendMessage"))
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+
+	mux.HandleFunc("sendMessage", func(context.Context, events.APIGatewayWebsocketProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: 202}, nil
+	})
+	resp, err = mux.ServeWebsocket(context.TODO(), websocketEvent("sendMessage"))
+	assert.NoError(t, err)
+	assert.Equal(t, 202, resp.StatusCode)
+}
+
+func TestWebsocketGateway_Serve_error(t *testing.T) {
+	g := NewWebsocketGateway(WebsocketHandlerFunc(func(context.Context, events.APIGatewayWebsocketProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{}, errors.New("boom")
+	}))
+	_, err := g.Serve(context.TODO(), websocketEvent(RouteConnect))
+	assert.EqualError(t, err, "boom")
+}
+
+func TestConnectionID(t *testing.T) {
+	_, ok := ConnectionID(context.TODO())
+	assert.False(t, ok)
+}